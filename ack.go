@@ -0,0 +1,162 @@
+package unlimitedchannel
+
+// SendWithAck sends a value to the channel, and calls ack once it has been sent to the output channel, or nack if it is discarded.
+//
+// It allows the producer to track the delivery without separate bookkeeping, e.g. for at-least-once processing.
+// The value is discarded if it doesn't reach the output channel: by an eviction policy or the other options (see WithOnDrop), by Filter, Drain or CloseAndCollectRemaining, or when the channel is closed with CloseImmediate.
+// The functions are called in the worker goroutine, so they must be fast and must not block.
+// A nil function is ignored.
+// Like a send on the input channel, it blocks until there is room (see WithMaxCapacity), and it panics if the channel is closed, unless WithConcurrentSafeClose is enabled, and nack is called.
+// nack is also called if the worker stopped receiving without the input channel being closed (see WithReleaseOnContextCancel, ForEach, CloseAfter and CloseAndCollectRemaining).
+// The value is ordered after the values previously sent to the input channel by the same goroutine.
+// The acknowledgements rely on the FIFO order of the queue, so it panics with WithPriority, WithCoalesceKey, WithConflate, WithWindowSort and NewLanes.
+func (c *Channel[T]) SendWithAck(v T, ack, nack func()) {
+	c.ensureInit()
+	if !c.isFIFO() {
+		panic(c.describe() + ": send with ack on a channel that reorders the values")
+	}
+	for {
+		closed, room := c.trySendWithAck(v, ack, nack)
+		if closed {
+			if !c.options.safeClose && !c.isInStopped() {
+				panic(c.describe() + ": send with ack on closed channel")
+			}
+			c.callAck(nack)
+			return
+		}
+		if room == nil {
+			return
+		}
+		select {
+		case <-room:
+		case <-c.done:
+		}
+	}
+}
+
+// trySendWithAck sends a value with its acknowledgement functions to the worker.
+//
+// If there is no room, the value is not sent, and it returns a channel that is closed when there is room.
+func (c *Channel[T]) trySendWithAck(v T, ack, nack func()) (closed bool, room chan struct{}) {
+	closed = true
+	c.do(func() {
+		c.receiveBuffered()
+		if c.inClosed {
+			return
+		}
+		closed = false
+		if !c.canReceive() {
+			room = make(chan struct{})
+			c.roomWaiters = append(c.roomWaiters, room)
+			return
+		}
+		if !c.receive(v) {
+			c.callAck(nack)
+			return
+		}
+		// The queue is FIFO, so the value is the last one.
+		c.acks = append(c.acks, ackItem{
+			pos:  c.headPos + uint64(c.queueLen()) - 1,
+			ack:  ack,
+			nack: nack,
+		})
+	})
+	return closed, room
+}
+
+// isFIFO returns true if the queue delivers the values in the order they were enqueued.
+func (c *Channel[T]) isFIFO() bool {
+	switch c.queue.(type) {
+	case *linkedQueue[T], *ringQueue[T], *ttlQueue[T]:
+		return true
+	}
+	return false
+}
+
+// notifyRoom notifies the SendWithAck callers if a value can be received.
+func (c *Channel[T]) notifyRoom() {
+	if len(c.roomWaiters) == 0 || !(c.inClosed || c.canReceive()) {
+		return
+	}
+	for _, room := range c.roomWaiters {
+		close(room)
+	}
+	c.roomWaiters = nil
+}
+
+// ackItem contains the acknowledgement functions of the value at a position of the queue, see SendWithAck.
+//
+// The position of the head of the queue is Channel.headPos.
+type ackItem struct {
+	pos  uint64
+	ack  func()
+	nack func()
+}
+
+// removeHead is called when the value at the head of the queue is removed.
+//
+// delivered indicates if it was sent to the output channel.
+// It also updates the positions of the sequence numbers (see All2) and the epoch boundaries (see MarkEpoch).
+func (c *Channel[T]) removeHead(delivered bool) {
+	if len(c.acks) > 0 && c.acks[0].pos == c.headPos {
+		item := c.acks[0]
+		c.acks[0] = ackItem{}
+		c.acks = c.acks[1:]
+		if delivered {
+			c.callAck(item.ack)
+		} else {
+			c.callAck(item.nack)
+		}
+	}
+	if !delivered {
+		c.epochHeadRemoved()
+	}
+	c.headPos++
+	if c.options.seqNumbers {
+		c.seqs.prune(c.headPos)
+	}
+}
+
+// ackFilter updates the acknowledgements when the queue is filtered.
+//
+// next must be called for each value of the queue, in order.
+type ackFilter struct {
+	acks    []ackItem
+	pos     uint64
+	removed uint64
+	kept    []ackItem
+	nacks   []func()
+}
+
+func (f *ackFilter) next(keep bool) {
+	if len(f.acks) > 0 && f.acks[0].pos == f.pos {
+		item := f.acks[0]
+		f.acks = f.acks[1:]
+		if keep {
+			// The kept values are moved toward the head.
+			item.pos -= f.removed
+			f.kept = append(f.kept, item)
+		} else {
+			f.nacks = append(f.nacks, item.nack)
+		}
+	}
+	if !keep {
+		f.removed++
+	}
+	f.pos++
+}
+
+// nackAll discards all the acknowledgements, when the queue is reset.
+func (c *Channel[T]) nackAll() {
+	acks := c.acks
+	c.acks = nil
+	for _, item := range acks {
+		c.callAck(item.nack)
+	}
+}
+
+func (c *Channel[T]) callAck(f func()) {
+	if f != nil {
+		c.callback(f)
+	}
+}
@@ -0,0 +1,127 @@
+package unlimitedchannel
+
+import (
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+// testAcks records the values acknowledged by SendWithAck.
+//
+// It is only modified in the worker goroutine, so it must be read after the worker is stopped.
+type testAcks struct {
+	acks  []int
+	nacks []int
+}
+
+func (a *testAcks) send(c *Channel[int], v int) {
+	c.SendWithAck(v, func() {
+		a.acks = append(a.acks, v)
+	}, func() {
+		a.nacks = append(a.nacks, v)
+	})
+}
+
+func TestSendWithAck(t *testing.T) {
+	c := New[int]()
+	var a testAcks
+	for i := 0; i < 100; i++ {
+		a.send(c, i)
+	}
+	out := c.Out()
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, <-out, i)
+	}
+	c.Close()
+	<-c.Done()
+	assert.SliceLen(t, a.acks, 100)
+	for i, v := range a.acks {
+		assert.Equal(t, v, i)
+	}
+	assert.SliceLen(t, a.nacks, 0)
+}
+
+func TestSendWithAckDropped(t *testing.T) {
+	c := New(WithMaxCapacity[int](2), WithDropOldest[int](true), WithStartPaused[int](true))
+	var a testAcks
+	for i := 0; i < 5; i++ {
+		a.send(c, i)
+	}
+	c.Resume()
+	out := c.Out()
+	assert.Equal(t, <-out, 3)
+	assert.Equal(t, <-out, 4)
+	c.Close()
+	<-c.Done()
+	assert.SliceEqual(t, a.acks, []int{3, 4})
+	assert.SliceEqual(t, a.nacks, []int{0, 1, 2})
+}
+
+func TestSendWithAckFilter(t *testing.T) {
+	c := New(WithStartPaused[int](true))
+	var a testAcks
+	for i := 0; i < 10; i++ {
+		a.send(c, i)
+	}
+	c.Filter(func(v int) bool {
+		return v%2 == 0
+	})
+	c.Resume()
+	out := c.Out()
+	for i := 0; i < 10; i += 2 {
+		assert.Equal(t, <-out, i)
+	}
+	c.Close()
+	<-c.Done()
+	assert.SliceEqual(t, a.acks, []int{0, 2, 4, 6, 8})
+	assert.SliceEqual(t, a.nacks, []int{1, 3, 5, 7, 9})
+}
+
+func TestSendWithAckMaxCapacity(t *testing.T) {
+	c := New(WithMaxCapacity[int](1))
+	var a testAcks
+	a.send(c, 0)
+	sent := make(chan struct{})
+	go func() {
+		a.send(c, 1)
+		close(sent)
+	}()
+	out := c.Out()
+	assert.Equal(t, <-out, 0)
+	<-sent
+	assert.Equal(t, <-out, 1)
+	c.Close()
+	<-c.Done()
+	assert.SliceEqual(t, a.acks, []int{0, 1})
+}
+
+func TestSendWithAckCloseImmediate(t *testing.T) {
+	c := New(WithStartPaused[int](true))
+	var a testAcks
+	for i := 0; i < 3; i++ {
+		a.send(c, i)
+	}
+	c.Close()
+	<-c.Done()
+	assert.SliceLen(t, a.acks, 0)
+	assert.SliceEqual(t, a.nacks, []int{0, 1, 2})
+}
+
+func TestSendWithAckClosed(t *testing.T) {
+	c := New[int]()
+	c.Close()
+	<-c.Done()
+	assert.Panics(t, func() {
+		c.SendWithAck(1, nil, nil)
+	})
+}
+
+func TestSendWithAckNotFIFO(t *testing.T) {
+	c := New(WithPriority(func(v int) int {
+		return v
+	}))
+	defer c.Close()
+	assert.Panics(t, func() {
+		c.SendWithAck(1, nil, nil)
+	})
+}
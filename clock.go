@@ -0,0 +1,73 @@
+package unlimitedchannel
+
+import (
+	"time"
+)
+
+// Clock provides the time to the time-based features.
+//
+// It allows to use a fake clock in tests.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a timer created by a Clock, see time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is a ticker created by a Clock, see time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// WithClock sets the Clock used by WithSnapshotInterval, WithMetrics, WithSoftLimit, WithValueTTL, WithSendTimeout, WithOutputRateLimit, Batch, Channel.CloseAfter and Channel.FlushAndWait.
+//
+// By default, it uses the real time.
+func WithClock[T any](clock Clock) Option[T] {
+	return func(o *options[T]) {
+		o.clock = clock
+	}
+}
+
+func (o *options[T]) getClock() Clock {
+	if o.clock != nil {
+		return o.clock
+	}
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
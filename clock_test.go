@@ -0,0 +1,182 @@
+package unlimitedchannel
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+)
+
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	tickers []*fakeTicker
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{
+		clock:    c,
+		c:        make(chan time.Time, 1),
+		deadline: c.now.Add(d),
+		active:   true,
+	}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{
+		clock:    c,
+		c:        make(chan time.Time, 1),
+		interval: d,
+		next:     c.now.Add(d),
+		active:   true,
+	}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Advance advances the time, and fires the timers and tickers.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		if t.active && !t.deadline.After(c.now) {
+			t.active = false
+			fakeSend(t.c, c.now)
+		}
+	}
+	for _, t := range c.tickers {
+		for t.active && !t.next.After(c.now) {
+			t.next = t.next.Add(t.interval)
+			fakeSend(t.c, c.now)
+		}
+	}
+}
+
+// activeTimers returns the number of active timers.
+func (c *fakeClock) activeTimers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, t := range c.timers {
+		if t.active {
+			n++
+		}
+	}
+	return n
+}
+
+func fakeSend(c chan time.Time, now time.Time) {
+	select {
+	case c <- now:
+	default:
+	}
+}
+
+type fakeTimer struct {
+	clock    *fakeClock
+	c        chan time.Time
+	deadline time.Time
+	active   bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.active
+	t.active = false
+	return active
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.active
+	t.deadline = t.clock.now.Add(d)
+	t.active = true
+	return active
+}
+
+type fakeTicker struct {
+	clock    *fakeClock
+	c        chan time.Time
+	interval time.Duration
+	next     time.Time
+	active   bool
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.active = false
+}
+
+func TestWithClockBatch(t *testing.T) {
+	clock := newFakeClock()
+	src := New[int]()
+	in := src.In()
+	defer close(in)
+	c := Batch(src, 100, time.Minute, WithClock[[]int](clock))
+	out := c.Out()
+	in <- 1
+	in <- 2
+	waitFor(t, func() bool {
+		return src.Stats().TotalDequeued == 2 && len(src.out) == 0 && clock.activeTimers() == 1
+	})
+	clock.Advance(time.Minute - time.Second)
+	select {
+	case <-out:
+		t.Fatal("should not be here")
+	default:
+	}
+	clock.Advance(time.Second)
+	batch := <-out
+	assert.SliceEqual(t, batch, []int{1, 2})
+}
+
+func TestWithClockMetrics(t *testing.T) {
+	clock := newFakeClock()
+	samples := make(chan Stats, 10)
+	c := New(
+		WithClock[int](clock),
+		WithMetrics[int](time.Minute, func(s Stats) {
+			samples <- s
+		}),
+	)
+	defer c.Close()
+	waitFor(t, func() bool {
+		clock.mu.Lock()
+		defer clock.mu.Unlock()
+		return len(clock.tickers) == 1
+	})
+	clock.Advance(time.Minute)
+	<-samples
+}
@@ -0,0 +1,113 @@
+package unlimitedchannel
+
+// WithCoalesceKey keeps only the latest value per key in the queue.
+//
+// When a received value has the same key as a value stored in the queue, it replaces it at the same position.
+// It implements the "latest value wins" semantic, e.g. for state synchronization.
+// A value is not compared to the values already sent to the output channel.
+// The replaced values are counted by DropCount, and passed to the function of WithOnDrop.
+// It takes precedence over WithPriority, WithQueueImpl and WithWindowSort, and it is ignored by NewLanes.
+func WithCoalesceKey[T any, K comparable](key func(T) K) Option[T] {
+	return func(o *options[T]) {
+		o.newCoalesceQueue = func() queue[T] {
+			return newCoalesceQueue(key)
+		}
+	}
+}
+
+// replacer is implemented by the queues that can replace a stored value.
+type replacer[T any] interface {
+	// replace replaces the stored value with the same key, and returns the old value.
+	replace(value T) (T, bool)
+}
+
+// replace replaces the value with the same key in the queue (see WithCoalesceKey), and returns true if it did.
+func (c *Channel[T]) replace(value T) bool {
+	if c.replacer == nil {
+		return false
+	}
+	old, ok := c.replacer.replace(value)
+	if ok {
+		c.bytes += c.sizeOf(value) - c.sizeOf(old)
+		c.drop(old, errDroppedReplaced)
+	}
+	return ok
+}
+
+// coalesceQueue stores the keys in FIFO order, and the latest value of each key.
+//
+// enqueue must only be called with a key that is not stored, so replace must be called before.
+type coalesceQueue[T any, K comparable] struct {
+	keys   linkedQueue[K]
+	values map[K]T
+	key    func(T) K
+}
+
+func newCoalesceQueue[T any, K comparable](key func(T) K) *coalesceQueue[T, K] {
+	return &coalesceQueue[T, K]{
+		keys: linkedQueue[K]{
+			allocator: new(allocator[K]),
+		},
+		values: make(map[K]T),
+		key:    key,
+	}
+}
+
+func (q *coalesceQueue[T, K]) replace(value T) (T, bool) {
+	k := q.key(value)
+	old, ok := q.values[k]
+	if ok {
+		q.values[k] = value
+	}
+	return old, ok
+}
+
+func (q *coalesceQueue[T, K]) enqueue(value T) {
+	k := q.key(value)
+	q.keys.enqueue(k)
+	q.values[k] = value
+}
+
+func (q *coalesceQueue[T, K]) dequeue() (T, bool) {
+	k, ok := q.keys.dequeue()
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	value := q.values[k]
+	delete(q.values, k)
+	return value, true
+}
+
+func (q *coalesceQueue[T, K]) pick() (T, bool) {
+	k, ok := q.keys.pick()
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return q.values[k], true
+}
+
+func (q *coalesceQueue[T, K]) filter(keep func(T) bool) int {
+	return q.keys.filter(func(k K) bool {
+		if keep(q.values[k]) {
+			return true
+		}
+		delete(q.values, k)
+		return false
+	})
+}
+
+func (q *coalesceQueue[T, K]) appendTo(s []T) []T {
+	for _, k := range q.keys.appendTo(nil) {
+		s = append(s, q.values[k])
+	}
+	return s
+}
+
+func (q *coalesceQueue[T, K]) flush() {}
+
+func (q *coalesceQueue[T, K]) reset() {
+	q.keys.reset()
+	clear(q.values)
+}
@@ -0,0 +1,101 @@
+package unlimitedchannel
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+type testCoalesceValue struct {
+	key   string
+	value int
+}
+
+func TestWithCoalesceKey(t *testing.T) {
+	c := New(WithCoalesceKey(func(v testCoalesceValue) string {
+		return v.key
+	}))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	fill := cap(c.out)
+	for i := 0; i < fill; i++ {
+		in <- testCoalesceValue{key: "fill" + strconv.Itoa(i)}
+	}
+	waitQueueLen(t, c, 0)
+	values := []testCoalesceValue{
+		{key: "a", value: 1},
+		{key: "b", value: 1},
+		{key: "a", value: 2},
+		{key: "c", value: 1},
+		{key: "b", value: 2},
+		{key: "a", value: 3},
+	}
+	for _, v := range values {
+		in <- v
+	}
+	waitFor(t, func() bool {
+		return c.Stats().TotalEnqueued == uint64(fill+len(values))
+	})
+	assert.Equal(t, c.queueLen(), 3)
+	assert.Equal(t, c.DropCount(), 3)
+	for i := 0; i < fill; i++ {
+		<-out
+	}
+	for _, expected := range []testCoalesceValue{
+		{key: "a", value: 3},
+		{key: "b", value: 2},
+		{key: "c", value: 1},
+	} {
+		v := <-out
+		assert.Equal(t, v, expected)
+	}
+}
+
+func TestCoalesceQueueFilter(t *testing.T) {
+	q := newCoalesceQueue(func(v int) int {
+		return v % 10
+	})
+	for i := 0; i < 5; i++ {
+		q.enqueue(i)
+	}
+	_, ok := q.replace(12)
+	assert.True(t, ok)
+	removed := q.filter(func(v int) bool {
+		return v%2 == 0
+	})
+	assert.Equal(t, removed, 2)
+	assert.DeepEqual(t, q.appendTo(nil), []int{0, 12, 4})
+	q.reset()
+	_, ok = q.pick()
+	assert.False(t, ok)
+}
+
+func TestWithCoalesceKeyWindowSort(t *testing.T) {
+	c := New(WithCoalesceKey(func(v testCoalesceValue) string {
+		return v.key
+	}), WithWindowSort(4, func(a, b testCoalesceValue) bool {
+		return a.value < b.value
+	}), WithSendAllOnClose[testCoalesceValue](true))
+	in := c.In()
+	for _, v := range []testCoalesceValue{
+		{key: "a", value: 1},
+		{key: "a", value: 2},
+		{key: "b", value: 3},
+		{key: "c", value: 4},
+	} {
+		in <- v
+	}
+	close(in)
+	var values []testCoalesceValue
+	for v := range c.Out() {
+		values = append(values, v)
+	}
+	assert.SliceEqual(t, values, []testCoalesceValue{
+		{key: "a", value: 2},
+		{key: "b", value: 3},
+		{key: "c", value: 4},
+	})
+	assert.Equal(t, c.DropCount(), 1)
+}
@@ -0,0 +1,65 @@
+package unlimitedchannel
+
+// WithConflate makes the channel hold at most one value: a received value replaces the value stored in the queue.
+//
+// So a slow consumer always receives the latest value, instead of a backlog, e.g. for a stream of states.
+// The replaced values are counted by DropCount, and passed to the function of WithOnDrop.
+// In order to deliver the latest value, the input and output channels are unbuffered.
+// It takes precedence over WithCoalesceKey, WithPriority, WithQueueImpl, WithValueTTL and WithWindowSort, and it is ignored by NewLanes.
+func WithConflate[T any](enabled bool) Option[T] {
+	return func(o *options[T]) {
+		o.conflate = enabled
+	}
+}
+
+// conflateQueue stores at most one value.
+//
+// enqueue must only be called if it is empty, so replace must be called before.
+type conflateQueue[T any] struct {
+	value T
+	ok    bool
+}
+
+func (q *conflateQueue[T]) replace(value T) (T, bool) {
+	old, ok := q.value, q.ok
+	if ok {
+		q.value = value
+	}
+	return old, ok
+}
+
+func (q *conflateQueue[T]) enqueue(value T) {
+	q.value, q.ok = value, true
+}
+
+func (q *conflateQueue[T]) dequeue() (T, bool) {
+	value, ok := q.value, q.ok
+	q.reset()
+	return value, ok
+}
+
+func (q *conflateQueue[T]) pick() (T, bool) {
+	return q.value, q.ok
+}
+
+func (q *conflateQueue[T]) filter(keep func(T) bool) int {
+	if !q.ok || keep(q.value) {
+		return 0
+	}
+	q.reset()
+	return 1
+}
+
+func (q *conflateQueue[T]) appendTo(s []T) []T {
+	if q.ok {
+		s = append(s, q.value)
+	}
+	return s
+}
+
+func (q *conflateQueue[T]) flush() {}
+
+func (q *conflateQueue[T]) reset() {
+	var zero T
+	q.value, q.ok = zero, false
+}
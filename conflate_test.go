@@ -0,0 +1,91 @@
+package unlimitedchannel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithConflate(t *testing.T) {
+	var dropped []int
+	c := New(
+		WithConflate[int](true),
+		WithOnDrop(func(v int) {
+			dropped = append(dropped, v)
+		}),
+	)
+	in := c.In()
+	out := c.Out()
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+	waitFor(t, func() bool {
+		return c.DropCount() == 99
+	})
+	assert.Equal(t, c.Len(), 1)
+	assert.Equal(t, <-out, 99)
+	in <- 100
+	in <- 101
+	assert.Equal(t, <-out, 101)
+	c.Close()
+	<-c.Done()
+	assert.Equal(t, c.DropCount(), 100)
+	assert.SliceLen(t, dropped, 100)
+	for i := 0; i < 99; i++ {
+		assert.Equal(t, dropped[i], i)
+	}
+	assert.Equal(t, dropped[99], 100)
+}
+
+func TestWithConflateSlowConsumer(t *testing.T) {
+	c := New(WithConflate[int](true))
+	in := c.In()
+	out := c.Out()
+	go func() {
+		defer close(in)
+		for i := 1; i <= 1000; i++ {
+			in <- i
+		}
+	}()
+	last := 0
+	for v := range out {
+		assert.Greater(t, v, last)
+		last = v
+	}
+	assert.LessOrEqual(t, last, 1000)
+}
+
+func TestWithConflateQueue(t *testing.T) {
+	c := New(WithConflate[int](true), WithStartPaused[int](true))
+	in := c.In()
+	in <- 1
+	in <- 2
+	assert.SliceEqual(t, c.Snapshot(), []int{2})
+	v, ok := c.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, v, 2)
+	assert.Equal(t, c.Filter(func(v int) bool {
+		return v != 2
+	}), 1)
+	assert.SliceLen(t, c.Snapshot(), 0)
+	c.Close()
+}
+
+func TestWithConflateWindowSort(t *testing.T) {
+	c := New(
+		WithConflate[int](true),
+		WithWindowSort(2, func(a, b int) bool {
+			return a < b
+		}),
+		WithSendAllOnClose[int](true),
+	)
+	in := c.In()
+	in <- 2
+	in <- 1
+	close(in)
+	values, err := c.Collect(context.Background())
+	assert.NoError(t, err)
+	assert.SliceEqual(t, values, []int{1})
+	assert.Equal(t, c.DropCount(), 1)
+}
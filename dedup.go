@@ -0,0 +1,25 @@
+package unlimitedchannel
+
+// WithDedup discards a received value if it is equal to the last value stored in the queue.
+//
+// It coalesces the consecutive duplicate values (e.g. "refresh" signals) that are waiting to be delivered.
+// A value is not compared to the values already sent to the output channel.
+// The discarded values are counted by DropCount, and passed to the function of WithOnDrop.
+func WithDedup[T any](equal func(a, b T) bool) Option[T] {
+	return func(o *options[T]) {
+		o.dedupEqual = equal
+	}
+}
+
+// isDuplicate returns true if the value must be discarded by WithDedup.
+func (c *Channel[T]) isDuplicate(value T) bool {
+	return c.options.dedupEqual != nil && c.lastValid && c.queueLen() > 0 && c.options.dedupEqual(c.last, value)
+}
+
+// setLast remembers the last value stored in the queue, for WithDedup.
+func (c *Channel[T]) setLast(value T) {
+	if c.options.dedupEqual != nil {
+		c.last = value
+		c.lastValid = true
+	}
+}
@@ -0,0 +1,39 @@
+package unlimitedchannel
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithDedup(t *testing.T) {
+	c := New(WithDedup(func(a, b string) bool {
+		return a == b
+	}))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	fill := cap(c.out)
+	for i := 0; i < fill; i++ {
+		in <- strconv.Itoa(i)
+	}
+	waitQueueLen(t, c, 0)
+	for _, v := range []string{"a", "a", "a", "b", "a", "a", "c", "c"} {
+		in <- v
+	}
+	waitFor(t, func() bool {
+		return c.Stats().TotalEnqueued == uint64(fill+8)
+	})
+	assert.Equal(t, c.DropCount(), 4)
+	for i := 0; i < fill; i++ {
+		<-out
+	}
+	for _, expected := range []string{"a", "b", "a", "c"} {
+		v := <-out
+		assert.Equal(t, v, expected)
+	}
+	in <- "c"
+	v := <-out
+	assert.Equal(t, v, "c")
+}
@@ -0,0 +1,84 @@
+package unlimitedchannel
+
+// MarkEpoch marks an epoch boundary after the values that were sent to the input channel before the call.
+//
+// The boundary is delivered by the channel returned by Epochs(), once all the values before it have been sent to the output channel.
+func (c *Channel[T]) MarkEpoch() {
+	c.do(func() {
+		c.receiveBuffered()
+		c.pendingEpochs = append(c.pendingEpochs, c.dequeued.Load()+uint64(c.queueLen()))
+	})
+}
+
+// Epochs returns the channel that receives the epoch boundaries marked by MarkEpoch().
+//
+// Each boundary is the number of values sent to the output channel before it.
+// The values removed from the queue without being sent (e.g. by Filter, Drain or an eviction policy) are not counted.
+// The output channel is buffered, so a boundary can be received before the values preceding it.
+// A consumer can align its windows by counting the values received from the output channel.
+// It is closed when the output channel is closed, and the pending boundaries are discarded.
+func (c *Channel[T]) Epochs() <-chan int {
+	c.ensureInit()
+	return c.epochs
+}
+
+// nextEpoch returns the epochs channel and the next boundary if it can be sent, or a nil channel otherwise.
+func (c *Channel[T]) nextEpoch() (chan int, int) {
+	if len(c.pendingEpochs) == 0 || c.pendingEpochs[0] > c.dequeued.Load() {
+		return nil, 0
+	}
+	return c.epochs, int(c.pendingEpochs[0])
+}
+
+func (c *Channel[T]) epochSent() {
+	c.pendingEpochs = c.pendingEpochs[1:]
+	if len(c.pendingEpochs) == 0 {
+		c.pendingEpochs = nil
+	}
+}
+
+// epochHeadRemoved is called when the value at the head of the queue is removed without being sent.
+//
+// It moves the boundaries after it toward the head.
+func (c *Channel[T]) epochHeadRemoved() {
+	dequeued := c.dequeued.Load()
+	for i, epoch := range c.pendingEpochs {
+		if epoch > dequeued {
+			c.pendingEpochs[i]--
+		}
+	}
+}
+
+// epochFilter updates the boundaries when the queue is filtered.
+//
+// A boundary is the number of values sent before it, so the position of the head of the queue is the number of values sent.
+//
+// next must be called for each value of the queue, in order, and then finish.
+type epochFilter struct {
+	epochs  []uint64
+	pos     uint64
+	removed uint64
+}
+
+func (f *epochFilter) next(keep bool) {
+	f.shift()
+	if !keep {
+		f.removed++
+	}
+	f.pos++
+}
+
+// finish moves the boundaries after the last value.
+func (f *epochFilter) finish() {
+	for i := range f.epochs {
+		f.epochs[i] -= f.removed
+	}
+}
+
+// shift moves the boundaries before the current position toward the head.
+func (f *epochFilter) shift() {
+	for len(f.epochs) > 0 && f.epochs[0] <= f.pos {
+		f.epochs[0] -= f.removed
+		f.epochs = f.epochs[1:]
+	}
+}
@@ -0,0 +1,87 @@
+package unlimitedchannel
+
+import (
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestEpoch(t *testing.T) {
+	c := new(Channel[int])
+	in := c.In()
+	out := c.Out()
+	epochs := c.Epochs()
+	for i := 0; i < 3; i++ {
+		in <- i
+	}
+	c.MarkEpoch()
+	for i := 3; i < 5; i++ {
+		in <- i
+	}
+	c.MarkEpoch()
+	c.MarkEpoch()
+	in <- 5
+	var received []int
+	var boundaries []int
+	for len(received) < 6 || len(boundaries) < 3 {
+		select {
+		case v := <-out:
+			received = append(received, v)
+		case epoch := <-epochs:
+			boundaries = append(boundaries, epoch)
+		}
+	}
+	assert.SliceEqual(t, received, []int{0, 1, 2, 3, 4, 5})
+	assert.SliceEqual(t, boundaries, []int{3, 5, 5})
+	close(in)
+	_, ok := <-epochs
+	assert.False(t, ok)
+}
+
+func TestEpochFilter(t *testing.T) {
+	c := new(Channel[int])
+	in := c.In()
+	out := c.Out()
+	epochs := c.Epochs()
+	defer close(in)
+	c.Pause()
+	for i := 0; i < 5; i++ {
+		in <- i
+	}
+	c.MarkEpoch()
+	in <- 5
+	c.MarkEpoch()
+	removed := c.Filter(func(v int) bool {
+		return v%2 == 0
+	})
+	assert.Equal(t, removed, 3)
+	c.Resume()
+	for _, expected := range []int{0, 2, 4} {
+		assert.Equal(t, <-out, expected)
+	}
+	assert.Equal(t, <-epochs, 3)
+	assert.Equal(t, <-epochs, 3)
+}
+
+func TestEpochDrain(t *testing.T) {
+	c := new(Channel[int])
+	in := c.In()
+	out := c.Out()
+	epochs := c.Epochs()
+	defer close(in)
+	c.Pause()
+	for i := 0; i < 5; i++ {
+		in <- i
+	}
+	c.MarkEpoch()
+	in <- 5
+	c.MarkEpoch()
+	assert.Equal(t, c.Drain(), 6)
+	c.Resume()
+	assert.Equal(t, <-epochs, 0)
+	assert.Equal(t, <-epochs, 0)
+	in <- 6
+	c.MarkEpoch()
+	assert.Equal(t, <-out, 6)
+	assert.Equal(t, <-epochs, 1)
+}
@@ -0,0 +1,56 @@
+package unlimitedchannel
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WithErrorChannel sets a channel that receives the non-fatal errors of the Channel.
+//
+// It reports the discarded values (see ErrDropped), and the panics recovered by WithPanicHandler (see PanicError).
+// The Channel continues to operate after an error.
+// The sends are non-blocking: the errors are discarded if the error channel is full, so it should be buffered.
+// The error channel is never closed by the Channel.
+func WithErrorChannel[T any](errs chan<- error) Option[T] {
+	return func(o *options[T]) {
+		o.errorChannel = errs
+	}
+}
+
+// ErrDropped is reported by WithErrorChannel when a value is discarded, see WithOnDrop.
+//
+// The reported error wraps it, and describes the reason.
+var ErrDropped = errors.New("value dropped")
+
+var (
+	errDroppedDuplicate   = newDropError("duplicate")
+	errDroppedReplaced    = newDropError("replaced by a newer value")
+	errDroppedSoftLimit   = newDropError("soft limit exceeded")
+	errDroppedCapacity    = newDropError("capacity reached")
+	errDroppedExpired     = newDropError("expired")
+	errDroppedSendTimeout = newDropError("send timeout")
+)
+
+func newDropError(reason string) error {
+	return fmt.Errorf("%w: %s", ErrDropped, reason)
+}
+
+// PanicError is reported by WithErrorChannel when a panic from a user callback is recovered by WithPanicHandler.
+type PanicError struct {
+	Value any
+}
+
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", err.Value)
+}
+
+// reportError sends an error to the channel of WithErrorChannel, if there is room.
+func (c *Channel[T]) reportError(err error) {
+	if c.options.errorChannel == nil {
+		return
+	}
+	select {
+	case c.options.errorChannel <- fmt.Errorf("%s: %w", c.describe(), err):
+	default:
+	}
+}
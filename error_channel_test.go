@@ -0,0 +1,73 @@
+package unlimitedchannel
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithErrorChannelDropped(t *testing.T) {
+	clock := newFakeClock()
+	errs := make(chan error, 10)
+	c := New(
+		WithName[int]("test"),
+		WithValueTTL[int](time.Second),
+		WithClock[int](clock),
+		WithErrorChannel[int](errs),
+		WithStartPaused[int](true),
+	)
+	in := c.In()
+	in <- 1
+	waitQueueLen(t, c, 1)
+	clock.Advance(time.Second)
+	// The expired values are discarded when the worker wakes up.
+	in <- 2
+	err := <-errs
+	assert.ErrorIs(t, err, ErrDropped)
+	assert.Equal(t, err.Error(), `unlimitedchannel "test": value dropped: expired`)
+	c.Close()
+}
+
+func TestWithErrorChannelPanic(t *testing.T) {
+	errs := make(chan error, 10)
+	c := New(
+		WithMaxCapacity[int](1),
+		WithDropNewest[int](true),
+		WithOnDrop(func(v int) {
+			panic(v)
+		}),
+		WithPanicHandler[int](func(any) {}),
+		WithErrorChannel[int](errs),
+	)
+	in := c.In()
+	in <- 1
+	in <- 2
+	err := <-errs
+	assert.ErrorIs(t, err, ErrDropped)
+	err = <-errs
+	var panicErr *PanicError
+	assert.True(t, errors.As(err, &panicErr))
+	assert.Equal(t, panicErr.Value, any(2))
+	assert.Equal(t, <-c.Out(), 1)
+	c.Close()
+}
+
+func TestWithErrorChannelFull(t *testing.T) {
+	errs := make(chan error)
+	c := New(
+		WithMaxCapacity[int](1),
+		WithDropNewest[int](true),
+		WithErrorChannel[int](errs),
+	)
+	in := c.In()
+	for i := 0; i < 10; i++ {
+		in <- i
+	}
+	waitFor(t, func() bool {
+		return c.DropCount() == 9
+	})
+	assert.Equal(t, <-c.Out(), 0)
+	c.Close()
+}
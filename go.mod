@@ -1,6 +1,6 @@
 module github.com/pierrre/unlimited-channel
 
-go 1.20
+go 1.23
 
 require (
 	github.com/pierrre/assert v0.1.6
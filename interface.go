@@ -0,0 +1,48 @@
+package unlimitedchannel
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/pierrre/go-libs/goroutine"
+)
+
+// AsInterface returns a channel that receives the values of the output channel, converted to the interface type I.
+//
+// Go doesn't allow to declare that T must implement I as a type constraint, so it panics if T doesn't implement I.
+// Go can't convert a channel to a channel of another element type, so the values are forwarded by a goroutine.
+// It stops when the output channel is closed and all values have been received, or when the context is canceled.
+// On cancellation, the value being forwarded is lost, and the other values stay in the output channel.
+// The returned channel is closed when it stops.
+func AsInterface[T, I any](ctx context.Context, c *Channel[T]) <-chan I {
+	typ := reflect.TypeFor[T]()
+	itfTyp := reflect.TypeFor[I]()
+	if itfTyp.Kind() != reflect.Interface || !typ.Implements(itfTyp) {
+		panic(fmt.Sprintf("unlimitedchannel: %s doesn't implement %s", typ, itfTyp))
+	}
+	out := c.Out()
+	res := make(chan I)
+	goroutine.Go(func() {
+		defer close(res)
+		for {
+			var v T
+			var ok bool
+			select {
+			case v, ok = <-out:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+			itf, _ := any(v).(I)
+			select {
+			case res <- itf:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return res
+}
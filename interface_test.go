@@ -0,0 +1,59 @@
+package unlimitedchannel
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+type testStringer int
+
+func (s testStringer) String() string {
+	return strconv.Itoa(int(s))
+}
+
+func TestAsInterface(t *testing.T) {
+	c := new(Channel[testStringer])
+	in := c.In()
+	out := AsInterface[testStringer, fmt.Stringer](context.Background(), c)
+	in <- 1
+	in <- 2
+	v := <-out
+	assert.Equal(t, v.String(), "1")
+	v = <-out
+	assert.Equal(t, v.String(), "2")
+	close(in)
+	_, ok := <-out
+	assert.False(t, ok)
+}
+
+func TestAsInterfaceCanceled(t *testing.T) {
+	c := new(Channel[testStringer])
+	in := c.In()
+	defer close(in)
+	ctx, cancel := context.WithCancel(context.Background())
+	out := AsInterface[testStringer, fmt.Stringer](ctx, c)
+	in <- 1
+	cancel()
+	for range out {
+	}
+}
+
+func TestAsInterfacePanicNotImplemented(t *testing.T) {
+	c := new(Channel[int])
+	defer close(c.In())
+	assert.Panics(t, func() {
+		AsInterface[int, fmt.Stringer](context.Background(), c)
+	})
+}
+
+func TestAsInterfacePanicNotInterface(t *testing.T) {
+	c := new(Channel[int])
+	defer close(c.In())
+	assert.Panics(t, func() {
+		AsInterface[int, int](context.Background(), c)
+	})
+}
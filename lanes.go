@@ -0,0 +1,98 @@
+package unlimitedchannel
+
+// NewLanes creates a Channel that delivers values from priority lanes.
+//
+// The level function returns the lane of a value, from 0 (lowest) to levels-1 (highest).
+// Levels out of this range are clamped.
+// The values of the highest non-empty lane are delivered first, and the values of a lane are delivered in FIFO order.
+//
+// The priority only applies to the values stored in the queue, not to the values already buffered in the output channel.
+func NewLanes[T any](levels int, levelFn func(T) int, opts ...Option[T]) *Channel[T] {
+	if levels < 1 {
+		levels = 1
+	}
+	c := &Channel[T]{
+		options: newOptions(opts),
+	}
+	lanes := make([]linkedQueue[T], levels)
+	alloc := newAllocator(&c.options)
+	for i := range lanes {
+		lanes[i].allocator = alloc
+	}
+	c.queue = &lanesQueue[T]{
+		lanes:   lanes,
+		levelFn: levelFn,
+	}
+	c.ensureInit()
+	return c
+}
+
+type lanesQueue[T any] struct {
+	lanes   []linkedQueue[T]
+	levelFn func(T) int
+}
+
+func (q *lanesQueue[T]) enqueue(value T) {
+	q.lanes[q.level(value)].enqueue(value)
+}
+
+func (q *lanesQueue[T]) dequeue() (T, bool) {
+	lane := q.highest()
+	if lane == nil {
+		var value T
+		return value, false
+	}
+	return lane.dequeue()
+}
+
+func (q *lanesQueue[T]) pick() (T, bool) {
+	lane := q.highest()
+	if lane == nil {
+		var value T
+		return value, false
+	}
+	return lane.pick()
+}
+
+func (q *lanesQueue[T]) filter(keep func(T) bool) int {
+	removed := 0
+	for i := range q.lanes {
+		removed += q.lanes[i].filter(keep)
+	}
+	return removed
+}
+
+func (q *lanesQueue[T]) appendTo(s []T) []T {
+	for i := len(q.lanes) - 1; i >= 0; i-- {
+		s = q.lanes[i].appendTo(s)
+	}
+	return s
+}
+
+func (q *lanesQueue[T]) flush() {}
+
+func (q *lanesQueue[T]) reset() {
+	for i := range q.lanes {
+		q.lanes[i].reset()
+	}
+}
+
+func (q *lanesQueue[T]) level(value T) int {
+	level := q.levelFn(value)
+	if level < 0 {
+		return 0
+	}
+	if level >= len(q.lanes) {
+		return len(q.lanes) - 1
+	}
+	return level
+}
+
+func (q *lanesQueue[T]) highest() *linkedQueue[T] {
+	for i := len(q.lanes) - 1; i >= 0; i-- {
+		if q.lanes[i].head != nil {
+			return &q.lanes[i]
+		}
+	}
+	return nil
+}
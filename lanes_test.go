@@ -0,0 +1,34 @@
+package unlimitedchannel
+
+import (
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestLanes(t *testing.T) {
+	c := NewLanes(3, func(v int) int {
+		return v / 100
+	})
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	fill := cap(c.out)
+	for i := 0; i < fill; i++ {
+		in <- i
+	}
+	waitQueueLen(t, c, 0)
+	values := []int{1, 2, 201, 101, 3, 202, 999, -1}
+	for _, v := range values {
+		in <- v
+	}
+	waitQueueLen(t, c, len(values))
+	for i := 0; i < fill; i++ {
+		v := <-out
+		assert.Equal(t, v, i)
+	}
+	for _, expected := range []int{201, 202, 999, 101, 1, 2, 3, -1} {
+		v := <-out
+		assert.Equal(t, v, expected)
+	}
+}
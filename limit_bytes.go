@@ -0,0 +1,38 @@
+package unlimitedchannel
+
+// WithQueueLimitBytes limits the estimated size in bytes of the values held by the channel.
+//
+// The sizeof function returns the size of a value, e.g. the length of a message.
+// It is more meaningful than WithMaxCapacity for the values with a variable size.
+// When the limit is reached, the worker stops receiving from the input channel, so sends block until values are received from the output channel.
+// The last received value can exceed the limit, because its size is only known once it is received.
+// With WithDropNewest, the values that would exceed the limit are discarded.
+// With WithDropOldest, the oldest values are discarded until the new value fits (a value larger than the limit is stored alone).
+// It can be combined with WithMaxCapacity, and the first limit reached applies.
+// In order to respect the limit, the input and output channels are unbuffered.
+// The sizeof function is called in the worker goroutine.
+// A zero or negative limit disables it.
+func WithQueueLimitBytes[T any](n int64, sizeof func(T) int64) Option[T] {
+	return func(o *options[T]) {
+		o.limitBytes = n
+		o.sizeof = sizeof
+	}
+}
+
+// sizeOf returns the size of a value, see WithQueueLimitBytes.
+func (c *Channel[T]) sizeOf(value T) int64 {
+	if c.options.limitBytes <= 0 {
+		return 0
+	}
+	return c.options.sizeof(value)
+}
+
+// limitBytesReached returns true if the size of the values stored in the queue reached the limit of WithQueueLimitBytes.
+func (c *Channel[T]) limitBytesReached() bool {
+	return c.options.limitBytes > 0 && c.bytes >= c.options.limitBytes
+}
+
+// limitBytesExceeded returns true if storing the value would exceed the limit of WithQueueLimitBytes.
+func (c *Channel[T]) limitBytesExceeded(value T) bool {
+	return c.options.limitBytes > 0 && c.bytes+c.sizeOf(value) > c.options.limitBytes
+}
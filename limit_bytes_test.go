@@ -0,0 +1,98 @@
+package unlimitedchannel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+)
+
+func newTestLimitBytesChannel(opts ...Option[string]) *Channel[string] {
+	opts = append([]Option[string]{
+		WithQueueLimitBytes(10, func(v string) int64 {
+			return int64(len(v))
+		}),
+		WithCloseMode[string](CloseDrain),
+	}, opts...)
+	return New(opts...)
+}
+
+func TestWithQueueLimitBytes(t *testing.T) {
+	c := newTestLimitBytesChannel()
+	in := c.In()
+	out := c.Out()
+	in <- "aaaa"
+	in <- "bbbb"
+	in <- "cc"
+	assert.False(t, c.TrySend("d"))
+	sent := make(chan struct{})
+	go func() {
+		in <- "d"
+		close(sent)
+	}()
+	select {
+	case <-sent:
+		t.Fatal("should block")
+	case <-time.After(10 * time.Millisecond):
+	}
+	assert.Equal(t, <-out, "aaaa")
+	<-sent
+	c.Close()
+	vs, err := c.Collect(context.Background())
+	assert.NoError(t, err)
+	assert.SliceEqual(t, vs, []string{"bbbb", "cc", "d"})
+	assert.Equal(t, c.DropCount(), 0)
+}
+
+func TestWithQueueLimitBytesDropNewest(t *testing.T) {
+	c := newTestLimitBytesChannel(WithDropNewest[string](true))
+	in := c.In()
+	in <- "aaaa"
+	in <- "bbbbbbb"
+	in <- "cc"
+	in <- "dddd"
+	c.Close()
+	vs, err := c.Collect(context.Background())
+	assert.NoError(t, err)
+	assert.SliceEqual(t, vs, []string{"aaaa", "cc", "dddd"})
+	assert.Equal(t, c.DropCount(), 1)
+}
+
+func TestWithQueueLimitBytesDropOldest(t *testing.T) {
+	var dropped []string
+	c := newTestLimitBytesChannel(
+		WithDropOldest[string](true),
+		WithOnDrop(func(v string) {
+			dropped = append(dropped, v)
+		}),
+	)
+	in := c.In()
+	in <- "aaaa"
+	in <- "bbbb"
+	in <- "cccccc"
+	assert.SliceEqual(t, c.Snapshot(), []string{"bbbb", "cccccc"})
+	in <- "dddddddddddd"
+	c.Close()
+	vs, err := c.Collect(context.Background())
+	assert.NoError(t, err)
+	assert.SliceEqual(t, vs, []string{"dddddddddddd"})
+	assert.SliceEqual(t, dropped, []string{"aaaa", "bbbb", "cccccc"})
+	assert.Equal(t, c.DropCount(), 3)
+}
+
+func TestWithQueueLimitBytesFilter(t *testing.T) {
+	c := newTestLimitBytesChannel()
+	in := c.In()
+	in <- "aaaa"
+	in <- "bbbbbb"
+	assert.False(t, c.TrySend("c"))
+	c.Filter(func(v string) bool {
+		return v != "bbbbbb"
+	})
+	assert.True(t, c.TrySend("cccccc"))
+	c.Close()
+	vs, err := c.Collect(context.Background())
+	assert.NoError(t, err)
+	assert.SliceEqual(t, vs, []string{"aaaa", "cccccc"})
+}
@@ -0,0 +1,204 @@
+package unlimitedchannel
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Codec encodes and decodes values.
+type Codec[T any] interface {
+	Encode(v T) ([]byte, error)
+	Decode(b []byte) (T, error)
+}
+
+// logSyncCount is the number of values written to the log between 2 syncs.
+const logSyncCount = 100
+
+// ConsumeToLog receives the values from the output channel, and appends them to a log file.
+//
+// Each value is encoded with the codec, and written as a record prefixed by its length.
+// The file is created if it doesn't exist, and synced every 100 values and before returning.
+// A truncated last record, e.g. after a crash during a write, is removed before appending.
+// It returns when the output channel is closed, or when the context is canceled.
+// The values can be read back with ReplayLog.
+func (c *Channel[T]) ConsumeToLog(ctx context.Context, path string, codec Codec[T]) (err error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("open log: %w", err)
+	}
+	defer func() {
+		closeErr := f.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("close log: %w", closeErr)
+		}
+	}()
+	err = repairLog(f)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	defer func() {
+		syncErr := syncLog(w, f)
+		if err == nil {
+			err = syncErr
+		}
+	}()
+	return c.consumeToLog(ctx, w, f, codec)
+}
+
+// repairLog removes the truncated last record of a log, and moves to the end of the log.
+func repairLog(f *os.File) error {
+	lr, err := newLogReader(f)
+	if err != nil {
+		return err
+	}
+	for {
+		_, err = lr.read()
+		if err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("read log: %w", err)
+	}
+	if lr.offset < lr.size {
+		err = f.Truncate(lr.offset)
+		if err != nil {
+			return fmt.Errorf("truncate log: %w", err)
+		}
+	}
+	_, err = f.Seek(lr.offset, io.SeekStart)
+	if err != nil {
+		return fmt.Errorf("seek log: %w", err)
+	}
+	return nil
+}
+
+func (c *Channel[T]) consumeToLog(ctx context.Context, w *bufio.Writer, f *os.File, codec Codec[T]) error {
+	out := c.Out()
+	var buf []byte
+	for n := 1; ; n++ {
+		select {
+		case v, ok := <-out:
+			if !ok {
+				return nil
+			}
+			b, err := codec.Encode(v)
+			if err != nil {
+				return fmt.Errorf("encode: %w", err)
+			}
+			buf = binary.AppendUvarint(buf[:0], uint64(len(b)))
+			buf = append(buf, b...)
+			_, err = w.Write(buf)
+			if err != nil {
+				return fmt.Errorf("write log: %w", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err() //nolint:wrapcheck // We don't need to wrap the context error.
+		}
+		if n%logSyncCount == 0 {
+			err := syncLog(w, f)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func syncLog(w *bufio.Writer, f *os.File) error {
+	err := w.Flush()
+	if err != nil {
+		return fmt.Errorf("flush log: %w", err)
+	}
+	err = f.Sync()
+	if err != nil {
+		return fmt.Errorf("sync log: %w", err)
+	}
+	return nil
+}
+
+// ReplayLog reads the values from a log file written by ConsumeToLog, and sends them in order to a channel.
+//
+// It doesn't close the channel.
+// It returns when all values have been sent, or when the context is canceled.
+// A truncated last record, e.g. after a crash during a write, is ignored.
+// Each record is decoded from a new buffer, so the codec can keep it.
+func ReplayLog[T any](ctx context.Context, path string, codec Codec[T], in chan<- T) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open log: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // The file is only read.
+	lr, err := newLogReader(f)
+	if err != nil {
+		return err
+	}
+	for {
+		b, err := lr.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return fmt.Errorf("read log: %w", err)
+		}
+		v, err := codec.Decode(b)
+		if err != nil {
+			return fmt.Errorf("decode: %w", err)
+		}
+		select {
+		case in <- v:
+		case <-ctx.Done():
+			return ctx.Err() //nolint:wrapcheck // We don't need to wrap the context error.
+		}
+	}
+}
+
+// logReader reads the records written by ConsumeToLog.
+type logReader struct {
+	r      *bufio.Reader
+	offset int64 // End of the last complete record.
+	size   int64 // Size of the file.
+}
+
+func newLogReader(f *os.File) (*logReader, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat log: %w", err)
+	}
+	return &logReader{
+		r:    bufio.NewReader(f),
+		size: fi.Size(),
+	}, nil
+}
+
+// read reads a record.
+//
+// It returns io.EOF at the end of the log, and io.ErrUnexpectedEOF if the record is truncated.
+// The length of the record is checked against the remaining size of the file, so a corrupted length doesn't allocate a huge buffer.
+func (lr *logReader) read() ([]byte, error) {
+	l, err := binary.ReadUvarint(lr.r)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // The error is wrapped by the caller.
+	}
+	var header [binary.MaxVarintLen64]byte
+	n := int64(binary.PutUvarint(header[:], l))
+	if l > uint64(lr.size-lr.offset-n) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := make([]byte, l)
+	_, err = io.ReadFull(lr.r, b)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			// The length was read, so the record is truncated.
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err //nolint:wrapcheck // The error is wrapped by the caller.
+	}
+	lr.offset += n + int64(l)
+	return b, nil
+}
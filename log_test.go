@@ -0,0 +1,172 @@
+package unlimitedchannel
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+type testIntCodec struct {
+	encoded  atomic.Int64
+	onEncode func(n int64)
+}
+
+func (c *testIntCodec) Encode(v int) ([]byte, error) {
+	n := c.encoded.Add(1)
+	if c.onEncode != nil {
+		c.onEncode(n)
+	}
+	return []byte(strconv.Itoa(v)), nil
+}
+
+func (c *testIntCodec) Decode(b []byte) (int, error) {
+	return strconv.Atoi(string(b)) //nolint:wrapcheck // Not needed in tests.
+}
+
+func TestConsumeToLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	const count = 250
+	writeTestLog(t, path, count)
+	replayed := make(chan int, count)
+	err := ReplayLog[int](context.Background(), path, new(testIntCodec), replayed)
+	assert.NoError(t, err)
+	close(replayed)
+	i := 0
+	for v := range replayed {
+		assert.Equal(t, v, i)
+		i++
+	}
+	assert.Equal(t, i, count)
+}
+
+func TestConsumeToLogClosed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	c := new(Channel[int])
+	close(c.In())
+	err := c.ConsumeToLog(context.Background(), path, new(testIntCodec))
+	assert.NoError(t, err)
+	replayed := make(chan int, 1)
+	err = ReplayLog[int](context.Background(), path, new(testIntCodec), replayed)
+	assert.NoError(t, err)
+	assert.ChanEmpty(t, replayed)
+}
+
+func TestReplayLogCanceled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	writeTestLog(t, path, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := ReplayLog[int](ctx, path, new(testIntCodec), make(chan int))
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestReplayLogTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	const count = 10
+	writeTestLog(t, path, count)
+	fi, err := os.Stat(path)
+	assert.NoError(t, err)
+	err = os.Truncate(path, fi.Size()-1)
+	assert.NoError(t, err)
+	replayed := make(chan int, count)
+	err = ReplayLog[int](context.Background(), path, new(testIntCodec), replayed)
+	assert.NoError(t, err)
+	close(replayed)
+	i := 0
+	for v := range replayed {
+		assert.Equal(t, v, i)
+		i++
+	}
+	assert.Equal(t, i, count-1)
+}
+
+type testBytesCodec struct{}
+
+func (testBytesCodec) Encode(v []byte) ([]byte, error) {
+	return v, nil
+}
+
+func (testBytesCodec) Decode(b []byte) ([]byte, error) {
+	return b, nil
+}
+
+func TestReplayLogKeepBuffer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	c := New(WithSendAllOnClose[[]byte](true))
+	in := c.In()
+	in <- []byte("aaa")
+	in <- []byte("bbb")
+	close(in)
+	err := c.ConsumeToLog(context.Background(), path, testBytesCodec{})
+	assert.NoError(t, err)
+	replayed := make(chan []byte, 2)
+	err = ReplayLog[[]byte](context.Background(), path, testBytesCodec{}, replayed)
+	assert.NoError(t, err)
+	assert.Equal(t, string(<-replayed), "aaa")
+	assert.Equal(t, string(<-replayed), "bbb")
+}
+
+// writeTestLog writes the values from 0 to count-1 to a log, and stops consuming once they have been encoded.
+func writeTestLog(tb testing.TB, path string, count int) {
+	tb.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	codec := &testIntCodec{
+		onEncode: func(n int64) {
+			if n == int64(count) {
+				cancel()
+			}
+		},
+	}
+	c := new(Channel[int])
+	in := c.In()
+	defer close(in)
+	for i := 0; i < count; i++ {
+		in <- i
+	}
+	err := c.ConsumeToLog(ctx, path, codec)
+	assert.ErrorIs(tb, err, context.Canceled)
+}
+
+func TestConsumeToLogRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	writeTestLog(t, path, 3)
+	// Simulate a crash during the write of a record.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	assert.NoError(t, err)
+	_, err = f.Write([]byte{10, '1'})
+	assert.NoError(t, err)
+	err = f.Close()
+	assert.NoError(t, err)
+	writeTestLog(t, path, 2)
+	replayed := make(chan int, 5)
+	err = ReplayLog[int](context.Background(), path, new(testIntCodec), replayed)
+	assert.NoError(t, err)
+	close(replayed)
+	var values []int
+	for v := range replayed {
+		values = append(values, v)
+	}
+	assert.SliceEqual(t, values, []int{0, 1, 2, 0, 1})
+}
+
+func TestReplayLogInvalidLength(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	writeTestLog(t, path, 1)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	assert.NoError(t, err)
+	_, err = f.Write(binary.AppendUvarint(nil, 1<<62))
+	assert.NoError(t, err)
+	err = f.Close()
+	assert.NoError(t, err)
+	replayed := make(chan int, 1)
+	err = ReplayLog[int](context.Background(), path, new(testIntCodec), replayed)
+	assert.NoError(t, err)
+	assert.Equal(t, <-replayed, 0)
+}
@@ -0,0 +1,38 @@
+package unlimitedchannel
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger sets a logger for the lifecycle events and the discarded values.
+//
+// The creation, the closing of the input channel, the release (see WithReleaseOnContextCancel) and the stop of the worker are logged at the debug level.
+// The discarded values (see WithOnDrop) are logged at the warn level, with the reason.
+// The records contain the name of the channel (see WithName) and its counters as attributes.
+// The logger is called in the worker goroutine, except for the creation.
+// By default, nothing is logged.
+func WithLogger[T any](logger *slog.Logger) Option[T] {
+	return func(o *options[T]) {
+		o.logger = logger
+	}
+}
+
+// log logs a record with the attributes of the channel, if a logger is set by WithLogger.
+func (c *Channel[T]) log(level slog.Level, msg string, attrs ...slog.Attr) {
+	l := c.options.logger
+	ctx := context.Background()
+	if l == nil || !l.Enabled(ctx, level) {
+		return
+	}
+	if c.options.name != "" {
+		attrs = append(attrs, slog.String("name", c.options.name))
+	}
+	attrs = append(attrs,
+		slog.Int("len", c.queueLen()),
+		slog.Uint64("enqueued", c.enqueued.Load()),
+		slog.Uint64("dequeued", c.dequeued.Load()),
+		slog.Uint64("dropped", c.dropped.Load()),
+	)
+	l.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,113 @@
+package unlimitedchannel
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+type testLogHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *testLogHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *testLogHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+
+func (h *testLogHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *testLogHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+func (h *testLogHandler) messages() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	msgs := make([]string, len(h.records))
+	for i, r := range h.records {
+		msgs[i] = r.Message
+	}
+	return msgs
+}
+
+func (h *testLogHandler) find(msg string) (slog.Record, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.records {
+		if r.Message == msg {
+			return r, true
+		}
+	}
+	return slog.Record{}, false
+}
+
+func recordAttrs(r slog.Record) map[string]string {
+	attrs := make(map[string]string)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.String()
+		return true
+	})
+	return attrs
+}
+
+func TestWithLogger(t *testing.T) {
+	h := new(testLogHandler)
+	c := New(
+		WithName[int]("test"),
+		WithLogger[int](slog.New(h)),
+		WithMaxCapacity[int](1),
+		WithDropNewest[int](true),
+	)
+	in := c.In()
+	in <- 1
+	in <- 2
+	waitFor(t, func() bool {
+		return c.DropCount() == 1
+	})
+	r, ok := h.find("unlimited channel value dropped")
+	assert.True(t, ok)
+	assert.Equal(t, r.Level, slog.LevelWarn)
+	attrs := recordAttrs(r)
+	assert.Equal(t, attrs["name"], "test")
+	assert.Equal(t, attrs["dropped"], "1")
+	assert.Equal(t, attrs["len"], "1")
+	assert.Equal(t, attrs["error"], "value dropped: capacity reached")
+	c.Close()
+	<-c.Done()
+	assert.SliceEqual(t, h.messages(), []string{
+		"unlimited channel created",
+		"unlimited channel value dropped",
+		"unlimited channel input closed",
+		"unlimited channel stopped",
+	})
+}
+
+func TestWithLoggerRelease(t *testing.T) {
+	h := new(testLogHandler)
+	ctx, cancel := context.WithCancel(context.Background())
+	c := New(
+		WithLogger[int](slog.New(h)),
+		WithReleaseOnContextCancel[int](ctx),
+	)
+	cancel()
+	<-c.Done()
+	assert.SliceEqual(t, h.messages(), []string{
+		"unlimited channel created",
+		"unlimited channel released",
+		"unlimited channel input closed",
+		"unlimited channel stopped",
+	})
+}
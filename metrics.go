@@ -0,0 +1,62 @@
+package unlimitedchannel
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WithMetrics calls a function periodically with the Stats of the channel.
+//
+// It allows to push metrics without polling.
+// The function is called in a dedicated goroutine, which stops when the worker stops (see Channel.Done).
+// A zero or negative interval disables it.
+func WithMetrics[T any](interval time.Duration, f func(Stats)) Option[T] {
+	return func(o *options[T]) {
+		o.metricsInterval = interval
+		o.metricsFunc = f
+	}
+}
+
+func (c *Channel[T]) runMetrics() {
+	ticker := c.options.getClock().NewTicker(c.options.metricsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C():
+			s := c.Stats()
+			c.callback(func() {
+				c.options.metricsFunc(s)
+			})
+		case <-c.done:
+			return
+		}
+	}
+}
+
+var expvarMu sync.Mutex
+
+// PublishExpvar publishes the Stats of the channel with the expvar package, under the given name.
+//
+// The value is visible as JSON in /debug/vars.
+// If the name is empty, the name set by WithName is used.
+// It returns an error if the name is empty or already registered.
+func (c *Channel[T]) PublishExpvar(name string) error {
+	if name == "" {
+		name = c.options.name
+	}
+	if name == "" {
+		return errors.New("empty expvar name")
+	}
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("expvar %q already registered", name)
+	}
+	expvar.Publish(name, expvar.Func(func() any {
+		return c.Stats()
+	}))
+	return nil
+}
@@ -0,0 +1,54 @@
+package unlimitedchannel
+
+import (
+	"encoding/json"
+	"expvar"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithMetrics(t *testing.T) {
+	samples := make(chan Stats, 100)
+	c := New(WithMetrics[int](time.Millisecond, func(s Stats) {
+		select {
+		case samples <- s:
+		default:
+		}
+	}))
+	in := c.In()
+	in <- 1
+	waitFor(t, func() bool {
+		return len(samples) > 0
+	})
+	s := <-samples
+	assert.LessOrEqual(t, s.TotalEnqueued, uint64(1))
+	c.Close()
+}
+
+// testExpvarCounter makes the expvar names unique, because they can't be unregistered, and the tests can run multiple times.
+var testExpvarCounter atomic.Int64
+
+func TestPublishExpvar(t *testing.T) {
+	name := "TestPublishExpvar" + strconv.FormatInt(testExpvarCounter.Add(1), 10)
+	c := New[int]()
+	in := c.In()
+	in <- 1
+	waitFor(t, func() bool {
+		return c.Stats().TotalEnqueued == 1
+	})
+	err := c.PublishExpvar(name)
+	assert.NoError(t, err)
+	var s Stats
+	err = json.Unmarshal([]byte(expvar.Get(name).String()), &s)
+	assert.NoError(t, err)
+	assert.Equal(t, s.TotalEnqueued, 1)
+	err = c.PublishExpvar(name)
+	assert.Error(t, err)
+	err = c.PublishExpvar("")
+	assert.Error(t, err)
+	c.Close()
+}
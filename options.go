@@ -0,0 +1,256 @@
+package unlimitedchannel
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// Option represents an option of a Channel.
+type Option[T any] func(*options[T])
+
+type options[T any] struct {
+	name             string
+	panicHandler     func(any)
+	errorChannel     chan<- error
+	logger           *slog.Logger
+	clock            Clock
+	pprofLabels      map[string]string
+	runner           *SharedRunner
+	watermarkLow     int
+	watermarkHigh    int
+	onHigh           func()
+	onLow            func()
+	snapshotInterval time.Duration
+	snapshotFunc     func([]T)
+	metricsInterval  time.Duration
+	metricsFunc      func(Stats)
+	maxCapacity      int
+	limitBytes       int64
+	sizeof           func(T) int64
+	sendTimeout      time.Duration
+	safeClose        bool
+	softLimit        int
+	softLimitTimeout time.Duration
+	dropOldest       bool
+	dropNewest       bool
+	onDrop           func(T)
+	onClose          func()
+	dedupEqual       func(a, b T) bool
+	requestMode      bool
+	seqNumbers       bool
+	outputRate       float64
+	outputBurst      int
+	startPaused      bool
+	closeMode        CloseMode
+	releaseCtx       context.Context //nolint:containedctx // It is only used to watch the cancellation.
+	strictSequential bool
+	windowSortSize   int
+	windowSortLess   func(a, b T) bool
+	queueImpl        QueueImpl
+	priority         func(T) int
+	newCoalesceQueue func() queue[T]
+	conflate         bool
+	valueTTL         time.Duration
+	initialCapacity  int
+	alloc            func() *Element[T]
+	free             func(*Element[T])
+}
+
+func newOptions[T any](opts []Option[T]) options[T] {
+	var o options[T]
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithName sets the name of the channel.
+//
+// It identifies the channel in the panic messages, the Stats and PublishExpvar.
+func WithName[T any](name string) Option[T] {
+	return func(o *options[T]) {
+		o.name = name
+	}
+}
+
+// WithSnapshotInterval calls a function periodically with a copy of the values stored in the queue, in delivery order.
+//
+// The values buffered in the input and output channels are not included.
+// The function is called in the worker goroutine, so it must be fast and must not block.
+// A zero or negative interval disables it.
+func WithSnapshotInterval[T any](interval time.Duration, f func([]T)) Option[T] {
+	return func(o *options[T]) {
+		o.snapshotInterval = interval
+		o.snapshotFunc = f
+	}
+}
+
+// WithMaxCapacity limits the number of values held by the channel.
+//
+// When the limit is reached, the worker stops receiving from the input channel, so sends block until values are received from the output channel.
+// In order to respect the limit, the input and output channels are unbuffered.
+// A zero or negative value means unlimited (default).
+func WithMaxCapacity[T any](n int) Option[T] {
+	return func(o *options[T]) {
+		o.maxCapacity = n
+	}
+}
+
+// ErrSendTimeout is returned by Channel.Send if the timeout set by WithSendTimeout expires.
+var ErrSendTimeout = errors.New("send timeout")
+
+// WithSendTimeout limits the time that Channel.Send can block, for example when the capacity set by WithMaxCapacity is reached.
+//
+// When the timeout expires, the value is discarded, and Send returns ErrSendTimeout.
+// The discarded values are counted by DropCount, and passed to the function of WithOnDrop.
+// The sends on the input channel returned by In() bypass the timeout.
+// The timeout uses the Clock set by WithClock.
+// A zero or negative duration disables it.
+func WithSendTimeout[T any](d time.Duration) Option[T] {
+	return func(o *options[T]) {
+		o.sendTimeout = d
+	}
+}
+
+// WithDropOldest enables the "drop oldest" eviction policy.
+//
+// When the capacity set by WithMaxCapacity (or the limit set by WithQueueLimitBytes) is reached, the oldest value of the queue is discarded in order to store the new value.
+// So sends on the input channel never block, and the output channel delivers the newest values.
+// It has no effect without a capacity.
+func WithDropOldest[T any](enabled bool) Option[T] {
+	return func(o *options[T]) {
+		o.dropOldest = enabled
+	}
+}
+
+// WithDropNewest enables the "drop newest" eviction policy.
+//
+// When the capacity set by WithMaxCapacity (or the limit set by WithQueueLimitBytes) is reached, the new values are discarded.
+// So sends on the input channel never block, and the output channel delivers the oldest values.
+// It takes precedence over WithDropOldest.
+// It has no effect without a capacity.
+func WithDropNewest[T any](enabled bool) Option[T] {
+	return func(o *options[T]) {
+		o.dropNewest = enabled
+	}
+}
+
+// WithOnDrop sets a function that is called for each value discarded by an eviction policy, WithDedup, WithCoalesceKey, WithConflate, WithSoftLimit, WithValueTTL or WithSendTimeout.
+//
+// It allows to release the resources held by the value.
+// The function is called in the worker goroutine, so it must be fast and must not block.
+func WithOnDrop[T any](f func(T)) Option[T] {
+	return func(o *options[T]) {
+		o.onDrop = f
+	}
+}
+
+// WithOnClose sets a function that is called once, when the output channel is closed.
+//
+// It is called in the worker goroutine, after the resources are released, and before Done is closed.
+// The values buffered in the output channel can still be received.
+// It allows to clean up, e.g. to flush the metrics or to close the downstream resources.
+func WithOnClose[T any](f func()) Option[T] {
+	return func(o *options[T]) {
+		o.onClose = f
+	}
+}
+
+// CloseMode defines what happens to the remaining values when the input channel is closed, or when the channel is released by WithReleaseOnContextCancel.
+type CloseMode int
+
+const (
+	// CloseImmediate discards the values remaining in the queue, and closes the output channel immediately (default).
+	// Only the values already buffered in the output channel can still be received.
+	CloseImmediate CloseMode = iota
+	// CloseDrain sends the values remaining in the queue, and closes the output channel once they have been received.
+	// On release, the values that are still buffered in the input channel are not received.
+	CloseDrain
+	// CloseSendAll is like CloseDrain, but on release, the values that are still buffered in the input channel are also received and sent.
+	// When the input channel is closed, it behaves like CloseDrain, because all the values sent before are received.
+	CloseSendAll
+)
+
+// WithCloseMode sets the CloseMode.
+func WithCloseMode[T any](mode CloseMode) Option[T] {
+	return func(o *options[T]) {
+		o.closeMode = mode
+	}
+}
+
+// WithSendAllOnClose sends all the values remaining in the queue when the input channel is closed.
+//
+// The output channel is closed once they have been received.
+// By default, they are discarded, and the output channel is closed immediately (only the values already buffered in it can still be received).
+// It is equivalent to WithCloseMode(CloseDrain) if enabled, or WithCloseMode(CloseImmediate) otherwise.
+func WithSendAllOnClose[T any](enabled bool) Option[T] {
+	return func(o *options[T]) {
+		o.closeMode = CloseImmediate
+		if enabled {
+			o.closeMode = CloseDrain
+		}
+	}
+}
+
+// WithReleaseOnContextCancel releases the channel when the context is canceled.
+//
+// The worker stops receiving from the input channel, as if it was closed.
+// Then the output channel is closed, immediately or after sending the remaining values (see WithCloseMode).
+// The input channel is not closed, so it is still safe to close it, but the values sent to it after the cancellation are never delivered.
+// Nothing receives from the input channel anymore, so a send on it can block forever.
+// The methods don't block: Send returns ErrClosed, SendBatch discards the values, and SendWithAck calls nack.
+func WithReleaseOnContextCancel[T any](ctx context.Context) Option[T] {
+	return func(o *options[T]) {
+		o.releaseCtx = ctx
+	}
+}
+
+// WithRequestMode enables the request mode.
+//
+// In this mode, the worker only sends values to the output channel when they were requested with Channel.Request().
+// It allows the consumer to control the delivery rate, even with the output channel buffer.
+func WithRequestMode[T any](enabled bool) Option[T] {
+	return func(o *options[T]) {
+		o.requestMode = enabled
+	}
+}
+
+// WithStrictSequential enables the strict sequential mode.
+//
+// In this mode, the input and output channels are unbuffered, and the worker holds at most one value (like WithMaxCapacity(1)).
+// A send on the input channel blocks until the previous value has been received from the output channel.
+// It gives the same backpressure as an unbuffered channel.
+func WithStrictSequential[T any](enabled bool) Option[T] {
+	return func(o *options[T]) {
+		o.strictSequential = enabled
+	}
+}
+
+// WithInitialCapacity pre-allocates the storage of the queue for n values.
+//
+// It allows to avoid allocations during the first burst of values.
+// It is different from the buffers of the input and output channels, which are not changed.
+// With QueueImplRing, the buffer doesn't shrink below this capacity.
+// It has no effect with WithAllocator, which is responsible for the allocation.
+func WithInitialCapacity[T any](n int) Option[T] {
+	return func(o *options[T]) {
+		o.initialCapacity = n
+	}
+}
+
+// WithAllocator sets the functions that allocate and free the chunks of the queue.
+//
+// It allows to use an arena or a slab allocator, instead of the default sync.Pool.
+// The alloc function must return a zero Element, for example with new(Element[T]).
+// The free function receives the chunks that are no longer used by the queue, with their fields cleared.
+// The chunks still in the queue when the input channel is closed are not freed.
+// Both functions are called in the worker goroutine.
+// A nil function uses the default sync.Pool.
+func WithAllocator[T any](alloc func() *Element[T], free func(*Element[T])) Option[T] {
+	return func(o *options[T]) {
+		o.alloc = alloc
+		o.free = free
+	}
+}
@@ -0,0 +1,132 @@
+package unlimitedchannel
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithName(t *testing.T) {
+	c := New(WithName[int]("test"))
+	assert.Equal(t, c.Name(), "test")
+	assert.Equal(t, c.Stats().Name, "test")
+	c.Close()
+	<-c.Done()
+	rec, _ := assert.Panics(t, func() {
+		c.SendBatch([]int{1})
+	})
+	assert.Equal(t, rec, any(`unlimitedchannel "test": send batch on closed channel`))
+}
+
+func TestWithSnapshotInterval(t *testing.T) {
+	snapshots := make(chan []int, 1)
+	c := New(WithSnapshotInterval(time.Millisecond, func(values []int) {
+		select {
+		case snapshots <- values:
+		default:
+		}
+	}))
+	in := c.In()
+	defer close(in)
+	fill := cap(c.out)
+	for i := 0; i < fill; i++ {
+		in <- -1
+	}
+	for i := 0; i < 3; i++ {
+		in <- i
+	}
+	waitQueueLen(t, c, 3)
+	deadline := time.After(10 * time.Second)
+	for {
+		select {
+		case values := <-snapshots:
+			if len(values) == 3 {
+				assert.SliceEqual(t, values, []int{0, 1, 2})
+				return
+			}
+		case <-deadline:
+			t.Fatal("timeout")
+		}
+	}
+}
+
+type testSlab struct {
+	elems  []*Element[int]
+	allocs int
+	frees  int
+}
+
+func (s *testSlab) alloc() *Element[int] {
+	s.allocs++
+	if n := len(s.elems); n > 0 {
+		elem := s.elems[n-1]
+		s.elems = s.elems[:n-1]
+		return elem
+	}
+	return new(Element[int])
+}
+
+func (s *testSlab) free(elem *Element[int]) {
+	s.frees++
+	s.elems = append(s.elems, elem)
+}
+
+func TestWithAllocator(t *testing.T) {
+	slab := new(testSlab)
+	c := New(WithAllocator(slab.alloc, slab.free))
+	in := c.In()
+	out := c.Out()
+	for i := 0; i < 20; i++ {
+		in <- i
+	}
+	for i := 0; i < 20; i++ {
+		v := <-out
+		assert.Equal(t, v, i)
+	}
+	close(in)
+	for range out {
+	}
+	assert.Greater(t, slab.allocs, 0)
+	assert.Equal(t, slab.frees, slab.allocs)
+}
+
+func BenchmarkWithAllocator(b *testing.B) {
+	for _, tc := range []struct {
+		name string
+		opts []Option[int]
+	}{
+		{
+			name: "Default",
+		},
+		{
+			name: "Slab",
+			opts: func() []Option[int] {
+				slab := new(testSlab)
+				return []Option[int]{WithAllocator(slab.alloc, slab.free)}
+			}(),
+		},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			c := New(tc.opts...)
+			in := c.In()
+			out := c.Out()
+			defer close(in)
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < 100; j++ {
+					in <- j
+				}
+				for j := 0; j < 100; j++ {
+					<-out
+				}
+			}
+			b.StopTimer()
+			runtime.ReadMemStats(&after)
+			b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package unlimitedchannel
+
+// WithPanicHandler sets a function that is called with the value of a panic from a user callback.
+//
+// It covers the functions of WithOnDrop, WithSnapshotInterval, WithMetrics, WithWatermarks and WithOnClose, the functions of SendWithAck, and the functions of Map and FilterStage (the value is skipped).
+// The panic is recovered, so the channel continues to operate, and it is reported by WithErrorChannel.
+// By default, the panic is not recovered.
+func WithPanicHandler[T any](f func(any)) Option[T] {
+	return func(o *options[T]) {
+		o.panicHandler = f
+	}
+}
+
+// callback calls a user function, and returns true if it didn't panic.
+//
+// If a handler is set by WithPanicHandler, the panic is recovered and forwarded to it.
+func (c *Channel[T]) callback(f func()) (ok bool) {
+	if c.options.panicHandler == nil {
+		f()
+		return true
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			c.reportError(&PanicError{Value: r})
+			c.options.panicHandler(r)
+		}
+	}()
+	f()
+	return true
+}
@@ -0,0 +1,54 @@
+package unlimitedchannel
+
+import (
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithPanicHandler(t *testing.T) {
+	panics := make(chan any, 10)
+	c := New(
+		WithMaxCapacity[int](1),
+		WithDropOldest[int](true),
+		WithOnDrop(func(v int) {
+			panic(v)
+		}),
+		WithPanicHandler[int](func(r any) {
+			panics <- r
+		}),
+	)
+	in := c.In()
+	out := c.Out()
+	in <- 1
+	in <- 2
+	in <- 3
+	r := <-panics
+	assert.Equal(t, r, any(1))
+	r = <-panics
+	assert.Equal(t, r, any(2))
+	v := <-out
+	assert.Equal(t, v, 3)
+	in <- 4
+	v = <-out
+	assert.Equal(t, v, 4)
+	c.Close()
+}
+
+func TestWithPanicHandlerMap(t *testing.T) {
+	panics := make(chan any, 10)
+	res := Map(newTestSource(5), func(v int) int {
+		if v == 2 {
+			panic("test")
+		}
+		return v
+	}, WithPanicHandler[int](func(r any) {
+		panics <- r
+	}))
+	var values []int
+	for v := range res.Out() {
+		values = append(values, v)
+	}
+	assert.DeepEqual(t, values, []int{0, 1, 3, 4})
+	assert.Equal(t, <-panics, any("test"))
+}
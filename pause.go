@@ -0,0 +1,28 @@
+package unlimitedchannel
+
+// WithStartPaused creates the Channel in the paused state, see Channel.Pause.
+//
+// It allows to buffer values before the consumer is ready, and to start the delivery with Channel.Resume.
+func WithStartPaused[T any](paused bool) Option[T] {
+	return func(o *options[T]) {
+		o.startPaused = paused
+	}
+}
+
+// Pause stops sending values to the output channel, until Resume is called.
+//
+// The worker keeps receiving values from the input channel, and stores them in the queue.
+// The values already buffered in the output channel can still be received.
+// When the input channel is closed, the remaining values are sent after Resume (see WithCloseMode).
+func (c *Channel[T]) Pause() {
+	c.do(func() {
+		c.paused = true
+	})
+}
+
+// Resume restarts sending values to the output channel, after Pause or WithStartPaused.
+func (c *Channel[T]) Resume() {
+	c.do(func() {
+		c.paused = false
+	})
+}
@@ -0,0 +1,45 @@
+package unlimitedchannel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithStartPaused(t *testing.T) {
+	c := New(WithStartPaused[int](true), WithSendAllOnClose[int](true))
+	in := c.In()
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+	waitQueueLen(t, c, 100)
+	assert.Equal(t, len(c.out), 0)
+	c.Close()
+	c.Resume()
+	vs, err := c.Collect(context.Background())
+	assert.NoError(t, err)
+	assert.SliceLen(t, vs, 100)
+	for i, v := range vs {
+		assert.Equal(t, v, i)
+	}
+}
+
+func TestPause(t *testing.T) {
+	c := New[int]()
+	in := c.In()
+	out := c.Out()
+	in <- 0
+	assert.Equal(t, <-out, 0)
+	c.Pause()
+	for i := 1; i < 100; i++ {
+		in <- i
+	}
+	waitQueueLen(t, c, 99)
+	assert.Equal(t, len(c.out), 0)
+	c.Resume()
+	for i := 1; i < 100; i++ {
+		assert.Equal(t, <-out, i)
+	}
+	c.Close()
+}
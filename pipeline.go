@@ -0,0 +1,229 @@
+package unlimitedchannel
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pierrre/go-libs/goroutine"
+)
+
+// newPipelineChannel creates a Channel that is fed by a goroutine.
+//
+// It sends all its values before closing, so closing the source doesn't lose the values forwarded to it.
+// It can be overridden by the options.
+func newPipelineChannel[T any](opts []Option[T]) *Channel[T] {
+	return New(append([]Option[T]{WithSendAllOnClose[T](true)}, opts...)...)
+}
+
+// Map creates a Channel that receives the values of another Channel, transformed by a function.
+//
+// The values are forwarded by a goroutine, which closes the input channel of the new Channel when the output channel of the source Channel is closed.
+// The new Channel sends all its values before closing (see WithSendAllOnClose), unless it is overridden by the options.
+func Map[A, B any](c *Channel[A], f func(A) B, opts ...Option[B]) *Channel[B] {
+	res := newPipelineChannel(opts)
+	out := c.Out()
+	in := res.In()
+	goroutine.Go(func() {
+		defer close(in)
+		for v := range out {
+			var w B
+			if res.callback(func() {
+				w = f(v)
+			}) {
+				in <- w
+			}
+		}
+	})
+	return res
+}
+
+// FilterStage creates a Channel that receives the values of another Channel for which a predicate returns true.
+//
+// The values are forwarded by a goroutine, which closes the input channel of the new Channel when the output channel of the source Channel is closed.
+// The new Channel sends all its values before closing (see WithSendAllOnClose), unless it is overridden by the options.
+// Unlike Channel.Filter, it doesn't remove the values stored in the queue of the source Channel.
+func FilterStage[T any](c *Channel[T], pred func(T) bool, opts ...Option[T]) *Channel[T] {
+	res := newPipelineChannel(opts)
+	out := c.Out()
+	in := res.In()
+	goroutine.Go(func() {
+		defer close(in)
+		for v := range out {
+			keep := false
+			if res.callback(func() {
+				keep = pred(v)
+			}) && keep {
+				in <- v
+			}
+		}
+	})
+	return res
+}
+
+// PipeTo forwards the values of the output channel to another channel, until the output channel is closed or the context is canceled.
+//
+// It blocks, so it is usually called in a goroutine.
+// The caller owns the destination channel: it is not closed by PipeTo.
+// If the context is canceled, the value being forwarded is lost.
+func (c *Channel[T]) PipeTo(ctx context.Context, dst chan<- T) {
+	out := c.Out()
+	for {
+		select {
+		case v, ok := <-out:
+			if !ok {
+				return
+			}
+			select {
+			case dst <- v:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// OutputWithContext returns a channel that receives the values of the output channel, and that is closed when the output channel is closed or the context is canceled.
+//
+// The values are forwarded by a goroutine, which stops when the returned channel is closed.
+// If the context is canceled, the value being forwarded is lost.
+func (c *Channel[T]) OutputWithContext(ctx context.Context) <-chan T {
+	ch := make(chan T)
+	goroutine.Go(func() {
+		defer close(ch)
+		c.PipeTo(ctx, ch)
+	})
+	return ch
+}
+
+// Merge creates a Channel that receives the values of several Channels.
+//
+// The values are forwarded by a goroutine per source Channel, and the order across the sources is unspecified.
+// The input channel of the new Channel is closed when the output channels of all the source Channels are closed.
+// The new Channel sends all its values before closing (see WithSendAllOnClose), unless it is overridden by the options.
+func Merge[T any](cs []*Channel[T], opts ...Option[T]) *Channel[T] {
+	res := newPipelineChannel(opts)
+	in := res.In()
+	wg := new(sync.WaitGroup)
+	for _, c := range cs {
+		out := c.Out()
+		goroutine.WaitGroup(wg, func() {
+			for v := range out {
+				in <- v
+			}
+		})
+	}
+	goroutine.Go(func() {
+		wg.Wait()
+		close(in)
+	})
+	return res
+}
+
+// Broadcast creates n Channels that receive a copy of each value of another Channel.
+//
+// The values are forwarded by a single goroutine, and each new Channel is unlimited, so a slow consumer doesn't block the others.
+// The input channels of the new Channels are closed when the output channel of the source Channel is closed.
+// The new Channels send all their values before closing (see WithSendAllOnClose), unless it is overridden by the options.
+func Broadcast[T any](c *Channel[T], n int, opts ...Option[T]) []*Channel[T] {
+	res := make([]*Channel[T], n)
+	ins := make([]chan<- T, n)
+	for i := range res {
+		res[i] = newPipelineChannel(opts)
+		ins[i] = res[i].In()
+	}
+	out := c.Out()
+	goroutine.Go(func() {
+		defer func() {
+			for _, in := range ins {
+				close(in)
+			}
+		}()
+		for v := range out {
+			for _, in := range ins {
+				in <- v
+			}
+		}
+	})
+	return res
+}
+
+// Tee creates 2 Channels that receive a copy of each value of another Channel.
+//
+// It is a shortcut for Broadcast with 2 Channels.
+func Tee[T any](c *Channel[T], opts ...Option[T]) (*Channel[T], *Channel[T]) {
+	cs := Broadcast(c, 2, opts...)
+	return cs[0], cs[1]
+}
+
+// Batch creates a Channel that receives the values of another Channel grouped in batches.
+//
+// A batch is sent when it contains maxSize values, or when maxWait has elapsed since its first value, whichever comes first.
+// A zero or negative maxSize or maxWait disables the corresponding limit.
+// The time is provided by the Clock set by WithClock.
+// The values are forwarded by a goroutine, which sends the last partial batch and closes the input channel of the new Channel when the output channel of the source Channel is closed.
+// The new Channel sends all its values before closing (see WithSendAllOnClose), unless it is overridden by the options.
+func Batch[T any](c *Channel[T], maxSize int, maxWait time.Duration, opts ...Option[[]T]) *Channel[[]T] {
+	res := newPipelineChannel(opts)
+	out := c.Out()
+	in := res.In()
+	goroutine.Go(func() {
+		defer close(in)
+		runBatch(out, in, maxSize, maxWait, res.options.getClock())
+	})
+	return res
+}
+
+func runBatch[T any](out <-chan T, in chan<- []T, maxSize int, maxWait time.Duration, clock Clock) {
+	timer := clock.NewTimer(maxWait)
+	timer.Stop()
+	defer timer.Stop()
+	var timerC <-chan time.Time
+	var batch []T
+	flush := func() {
+		timer.Stop()
+		timerC = nil
+		if len(batch) > 0 {
+			in <- batch
+			batch = nil
+		}
+	}
+	for {
+		select {
+		case v, ok := <-out:
+			if !ok {
+				flush()
+				return
+			}
+			if len(batch) == 0 && maxWait > 0 {
+				timer.Reset(maxWait)
+				timerC = timer.C()
+			}
+			batch = append(batch, v)
+			if maxSize > 0 && len(batch) >= maxSize {
+				flush()
+			}
+		case <-timerC:
+			flush()
+		}
+	}
+}
+
+// Reduce folds the values of the output channel of a Channel into an accumulator, until it is closed.
+//
+// If the context is canceled before, it returns the accumulator so far and the context error.
+func Reduce[T, A any](ctx context.Context, c *Channel[T], initial A, f func(A, T) A) (A, error) {
+	acc := initial
+	for {
+		v, ok, err := c.Receive(ctx)
+		if err != nil {
+			return acc, err
+		}
+		if !ok {
+			return acc, nil
+		}
+		acc = f(acc, v)
+	}
+}
@@ -0,0 +1,182 @@
+package unlimitedchannel
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+)
+
+func newTestSource(count int) *Channel[int] {
+	c := New(WithSendAllOnClose[int](true))
+	in := c.In()
+	for i := 0; i < count; i++ {
+		in <- i
+	}
+	close(in)
+	return c
+}
+
+func TestMap(t *testing.T) {
+	c := Map(newTestSource(100), strconv.Itoa)
+	i := 0
+	for v := range c.Out() {
+		assert.Equal(t, v, strconv.Itoa(i))
+		i++
+	}
+	assert.Equal(t, i, 100)
+}
+
+func TestFilterStage(t *testing.T) {
+	c := FilterStage(newTestSource(100), func(v int) bool {
+		return v%2 == 0
+	})
+	var values []int
+	for v := range c.Out() {
+		values = append(values, v)
+	}
+	assert.SliceLen(t, values, 50)
+	for i, v := range values {
+		assert.Equal(t, v, i*2)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	c := Merge([]*Channel[int]{
+		newTestSource(10),
+		newTestSource(20),
+		newTestSource(30),
+	})
+	sum := 0
+	count := 0
+	for v := range c.Out() {
+		sum += v
+		count++
+	}
+	assert.Equal(t, count, 60)
+	assert.Equal(t, sum, 45+190+435)
+}
+
+func TestMergeEmpty(t *testing.T) {
+	c := Merge[int](nil)
+	_, ok := <-c.Out()
+	assert.False(t, ok)
+}
+
+func TestBroadcast(t *testing.T) {
+	cs := Broadcast(newTestSource(100), 3)
+	assert.SliceLen(t, cs, 3)
+	for _, c := range cs {
+		i := 0
+		for v := range c.Out() {
+			assert.Equal(t, v, i)
+			i++
+		}
+		assert.Equal(t, i, 100)
+	}
+}
+
+func TestTee(t *testing.T) {
+	c1, c2 := Tee(newTestSource(100))
+	vs1, err := c1.Collect(context.Background())
+	assert.NoError(t, err)
+	vs2, err := c2.Collect(context.Background())
+	assert.NoError(t, err)
+	assert.SliceLen(t, vs1, 100)
+	assert.SliceEqual(t, vs1, vs2)
+}
+
+func TestBatchSize(t *testing.T) {
+	c := Batch(newTestSource(10), 4, time.Hour)
+	var batches [][]int
+	for batch := range c.Out() {
+		batches = append(batches, batch)
+	}
+	assert.DeepEqual(t, batches, [][]int{{0, 1, 2, 3}, {4, 5, 6, 7}, {8, 9}})
+}
+
+func TestBatchWait(t *testing.T) {
+	src := new(Channel[int])
+	in := src.In()
+	defer close(in)
+	c := Batch(src, 100, 50*time.Millisecond)
+	out := c.Out()
+	in <- 1
+	in <- 2
+	batch := <-out
+	assert.SliceEqual(t, batch, []int{1, 2})
+	in <- 3
+	batch = <-out
+	assert.SliceEqual(t, batch, []int{3})
+}
+
+func TestPipeTo(t *testing.T) {
+	dst := make(chan int, 100)
+	newTestSource(100).PipeTo(context.Background(), dst)
+	assert.Equal(t, len(dst), 100)
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, <-dst, i)
+	}
+}
+
+func TestPipeToCanceled(t *testing.T) {
+	c := New[int]()
+	in := c.In()
+	in <- 1
+	dst := make(chan int)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.PipeTo(ctx, dst)
+	}()
+	cancel()
+	<-done
+	c.Close()
+}
+
+func TestOutputWithContext(t *testing.T) {
+	i := 0
+	for v := range newTestSource(100).OutputWithContext(context.Background()) {
+		assert.Equal(t, v, i)
+		i++
+	}
+	assert.Equal(t, i, 100)
+}
+
+func TestOutputWithContextCanceled(t *testing.T) {
+	c := New[int]()
+	in := c.In()
+	defer close(in)
+	ctx, cancel := context.WithCancel(context.Background())
+	out := c.OutputWithContext(ctx)
+	in <- 1
+	assert.Equal(t, <-out, 1)
+	cancel()
+	for range out {
+	}
+}
+
+func TestReduce(t *testing.T) {
+	sum, err := Reduce(context.Background(), newTestSource(100), 0, func(acc, v int) int {
+		return acc + v
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, sum, 4950)
+}
+
+func TestReduceCanceled(t *testing.T) {
+	c := New[int]()
+	in := c.In()
+	defer close(in)
+	in <- 1
+	ctx, cancel := context.WithCancel(context.Background())
+	sum, err := Reduce(ctx, c, 0, func(acc, v int) int {
+		cancel()
+		return acc + v
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, sum, 1)
+}
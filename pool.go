@@ -0,0 +1,39 @@
+package unlimitedchannel
+
+import (
+	"errors"
+	"sync"
+)
+
+// Pool recycles closed Channels, see Channel.Reset.
+//
+// It allows to reduce the allocations when short-lived channels are created frequently.
+// The zero value is ready to use.
+type Pool[T any] struct {
+	pool sync.Pool
+}
+
+// Get returns a Channel configured with the options.
+//
+// It is a recycled Channel if available, or a new one otherwise.
+func (p *Pool[T]) Get(opts ...Option[T]) *Channel[T] {
+	cItf := p.pool.Get()
+	if cItf == nil {
+		return New(opts...)
+	}
+	c := cItf.(*Channel[T]) //nolint:forcetypeassert // The pool only contains *Channel[T].
+	c.Reset(opts...)
+	return c
+}
+
+// Put adds a Channel to the pool.
+//
+// The worker must have stopped (see Channel.Done), otherwise it returns an error.
+// The Channel must not be used after.
+func (p *Pool[T]) Put(c *Channel[T]) error {
+	if !c.Closed() {
+		return errors.New(c.describe() + ": put of an active channel")
+	}
+	p.pool.Put(c)
+	return nil
+}
@@ -0,0 +1,51 @@
+package unlimitedchannel
+
+import (
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestPool(t *testing.T) {
+	var p Pool[int]
+	for i := 0; i < 3; i++ {
+		c := p.Get()
+		in := c.In()
+		out := c.Out()
+		in <- i
+		assert.Equal(t, <-out, i)
+		err := p.Put(c)
+		assert.Error(t, err)
+		c.Close()
+		<-c.Done()
+		err = p.Put(c)
+		assert.NoError(t, err)
+	}
+}
+
+func BenchmarkPool(b *testing.B) {
+	b.Run("New", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchmarkPoolUse(New[int]())
+		}
+	})
+	b.Run("Pool", func(b *testing.B) {
+		var p Pool[int]
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c := p.Get()
+			benchmarkPoolUse(c)
+			_ = p.Put(c)
+		}
+	})
+}
+
+func benchmarkPoolUse(c *Channel[int]) {
+	in := c.In()
+	out := c.Out()
+	in <- 1
+	<-out
+	c.Close()
+	<-c.Done()
+}
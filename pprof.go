@@ -0,0 +1,35 @@
+package unlimitedchannel
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// WithPprofLabels runs the worker goroutine with pprof labels.
+//
+// It allows to identify the worker of a channel in the profiles.
+// The labels of the context set by WithReleaseOnContextCancel are inherited.
+func WithPprofLabels[T any](labels map[string]string) Option[T] {
+	return func(o *options[T]) {
+		o.pprofLabels = labels
+	}
+}
+
+// runWorker runs the worker, with the labels set by WithPprofLabels.
+func (c *Channel[T]) runWorker() {
+	if len(c.options.pprofLabels) == 0 {
+		c.run()
+		return
+	}
+	ctx := c.options.releaseCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	args := make([]string, 0, len(c.options.pprofLabels)*2)
+	for k, v := range c.options.pprofLabels {
+		args = append(args, k, v)
+	}
+	pprof.Do(ctx, pprof.Labels(args...), func(context.Context) {
+		c.run()
+	})
+}
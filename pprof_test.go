@@ -0,0 +1,24 @@
+package unlimitedchannel
+
+import (
+	"bytes"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithPprofLabels(t *testing.T) {
+	c := New(WithPprofLabels[int](map[string]string{
+		"channel": "TestWithPprofLabels",
+	}))
+	in := c.In()
+	out := c.Out()
+	in <- 1
+	assert.Equal(t, <-out, 1)
+	buf := new(bytes.Buffer)
+	err := pprof.Lookup("goroutine").WriteTo(buf, 1)
+	assert.NoError(t, err)
+	assert.StringContains(t, buf.String(), `"channel":"TestWithPprofLabels"`)
+	c.Close()
+}
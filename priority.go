@@ -0,0 +1,126 @@
+package unlimitedchannel
+
+import (
+	"container/heap"
+	"slices"
+)
+
+// WithPriority delivers the values by priority, the highest first.
+//
+// The FIFO order is not guaranteed anymore: the values with the same priority are delivered in insertion order.
+// The priority only applies to the values stored in the queue, not to the values already buffered in the output channel.
+// It takes precedence over WithQueueImpl, and it is ignored by NewLanes.
+func WithPriority[T any](priority func(T) int) Option[T] {
+	return func(o *options[T]) {
+		o.priority = priority
+	}
+}
+
+type priorityItem[T any] struct {
+	value    T
+	priority int
+	seq      uint64
+}
+
+// priorityQueue is a binary heap.
+type priorityQueue[T any] struct {
+	items    priorityItems[T]
+	priority func(T) int
+	seq      uint64
+}
+
+func newPriorityQueue[T any](priority func(T) int) *priorityQueue[T] {
+	return &priorityQueue[T]{
+		priority: priority,
+	}
+}
+
+func (q *priorityQueue[T]) enqueue(value T) {
+	heap.Push(&q.items, priorityItem[T]{
+		value:    value,
+		priority: q.priority(value),
+		seq:      q.seq,
+	})
+	q.seq++
+}
+
+func (q *priorityQueue[T]) dequeue() (T, bool) {
+	if len(q.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	item := heap.Pop(&q.items).(priorityItem[T]) //nolint:forcetypeassert // The heap only contains priorityItem[T].
+	return item.value, true
+}
+
+func (q *priorityQueue[T]) pick() (T, bool) {
+	if len(q.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return q.items[0].value, true
+}
+
+func (q *priorityQueue[T]) filter(keep func(T) bool) int {
+	l := len(q.items)
+	q.items = slices.DeleteFunc(q.items, func(item priorityItem[T]) bool {
+		return !keep(item.value)
+	})
+	heap.Init(&q.items)
+	return l - len(q.items)
+}
+
+func (q *priorityQueue[T]) appendTo(s []T) []T {
+	items := slices.Clone(q.items)
+	slices.SortFunc(items, func(a, b priorityItem[T]) int {
+		if items.before(a, b) {
+			return -1
+		}
+		return 1
+	})
+	for _, item := range items {
+		s = append(s, item.value)
+	}
+	return s
+}
+
+func (q *priorityQueue[T]) flush() {}
+
+func (q *priorityQueue[T]) reset() {
+	q.items = nil
+}
+
+// priorityItems implements heap.Interface.
+type priorityItems[T any] []priorityItem[T]
+
+func (items priorityItems[T]) Len() int {
+	return len(items)
+}
+
+func (items priorityItems[T]) Less(i, j int) bool {
+	return items.before(items[i], items[j])
+}
+
+func (items priorityItems[T]) before(a, b priorityItem[T]) bool {
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	return a.seq < b.seq
+}
+
+func (items priorityItems[T]) Swap(i, j int) {
+	items[i], items[j] = items[j], items[i]
+}
+
+func (items *priorityItems[T]) Push(x any) {
+	*items = append(*items, x.(priorityItem[T])) //nolint:forcetypeassert // The heap only contains priorityItem[T].
+}
+
+func (items *priorityItems[T]) Pop() any {
+	old := *items
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = priorityItem[T]{}
+	*items = old[:n-1]
+	return item
+}
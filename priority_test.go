@@ -0,0 +1,58 @@
+package unlimitedchannel
+
+import (
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithPriority(t *testing.T) {
+	c := New(WithPriority(func(v int) int {
+		return v / 100
+	}))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	fill := cap(c.out)
+	for i := 0; i < fill; i++ {
+		in <- i
+	}
+	waitQueueLen(t, c, 0)
+	values := []int{1, 2, 201, 101, 3, 202, 999, -1}
+	for _, v := range values {
+		in <- v
+	}
+	waitQueueLen(t, c, len(values))
+	for i := 0; i < fill; i++ {
+		v := <-out
+		assert.Equal(t, v, i)
+	}
+	for _, expected := range []int{999, 201, 202, 101, 1, 2, 3, -1} {
+		v := <-out
+		assert.Equal(t, v, expected)
+	}
+}
+
+func TestPriorityQueue(t *testing.T) {
+	q := newPriorityQueue(func(v int) int {
+		return v % 3
+	})
+	for i := 0; i < 9; i++ {
+		q.enqueue(i)
+	}
+	assert.DeepEqual(t, q.appendTo(nil), []int{2, 5, 8, 1, 4, 7, 0, 3, 6})
+	removed := q.filter(func(v int) bool {
+		return v < 6
+	})
+	assert.Equal(t, removed, 3)
+	for _, expected := range []int{2, 5, 1, 4, 0, 3} {
+		v, ok := q.pick()
+		assert.True(t, ok)
+		assert.Equal(t, v, expected)
+		v, ok = q.dequeue()
+		assert.True(t, ok)
+		assert.Equal(t, v, expected)
+	}
+	_, ok := q.dequeue()
+	assert.False(t, ok)
+}
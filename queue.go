@@ -4,63 +4,226 @@ import (
 	"sync"
 )
 
-type queue[T any] struct {
-	head *queueElement[T]
-	tail *queueElement[T]
-
-	elemPool sync.Pool
+type queue[T any] interface {
+	enqueue(value T)
+	dequeue() (T, bool)
+	pick() (T, bool)
+	filter(keep func(T) bool) int
+	appendTo(s []T) []T
+	// flush makes all the values available, because no more value will be enqueued.
+	flush()
+	reset()
 }
 
-func (q *queue[T]) enqueue(value T) {
-	newElemItf := q.elemPool.Get()
-	var newElem *queueElement[T]
-	if newElemItf != nil {
-		newElem = newElemItf.(*queueElement[T]) //nolint:forcetypeassert // The pool only contains *queueElement[T].
-	} else {
-		newElem = &queueElement[T]{}
+func newQueue[T any](o *options[T]) queue[T] {
+	if o.conflate {
+		return new(conflateQueue[T])
 	}
-	newElem.value = value
-	if q.head == nil {
-		q.head = newElem
+	if o.newCoalesceQueue != nil {
+		return o.newCoalesceQueue()
+	}
+	if o.priority != nil {
+		return newPriorityQueue(o.priority)
+	}
+	if o.valueTTL > 0 {
+		return newTTLQueue[T](o.valueTTL, o.getClock())
+	}
+	if o.queueImpl == QueueImplRing {
+		return newRingQueue[T](o.initialCapacity)
+	}
+	return &linkedQueue[T]{
+		allocator: newAllocator(o),
 	}
-	if q.tail != nil {
-		q.tail.next = newElem
+}
+
+// linkedQueue is a linked list of chunks.
+//
+// Each chunk contains elementSize values, so the allocations are amortized.
+// The values are read from the head chunk, and written to the tail chunk.
+// If the queue is empty, head and tail are nil.
+type linkedQueue[T any] struct {
+	head      *Element[T]
+	headIndex int // Index of the first value in the head chunk.
+	tail      *Element[T]
+	tailIndex int // Index of the next value in the tail chunk.
+
+	allocator *allocator[T]
+}
+
+func (q *linkedQueue[T]) enqueue(value T) {
+	if q.tail == nil || q.tailIndex == elementSize {
+		newElem := q.allocator.get()
+		if q.tail == nil {
+			q.head = newElem
+		} else {
+			q.tail.next = newElem
+		}
+		q.tail = newElem
+		q.tailIndex = 0
 	}
-	q.tail = newElem
+	q.tail.values[q.tailIndex] = value
+	q.tailIndex++
 }
 
-func (q *queue[T]) dequeue() (T, bool) {
+func (q *linkedQueue[T]) dequeue() (T, bool) {
 	if q.head == nil {
 		var value T
 		return value, false
 	}
-	oldElem := q.head
-	value := oldElem.value
-	q.head = oldElem.next
-	if q.head == nil {
-		q.tail = nil
-	}
 	var zero T
-	oldElem.value = zero
-	oldElem.next = nil
-	q.elemPool.Put(oldElem)
+	value := q.head.values[q.headIndex]
+	q.head.values[q.headIndex] = zero
+	q.headIndex++
+	switch {
+	case q.head == q.tail && q.headIndex == q.tailIndex:
+		q.release()
+	case q.headIndex == elementSize:
+		next := q.head.next
+		q.allocator.put(q.head)
+		q.head = next
+		q.headIndex = 0
+	}
 	return value, true
 }
 
-func (q *queue[T]) pick() (T, bool) {
+func (q *linkedQueue[T]) pick() (T, bool) {
 	if q.head == nil {
 		var value T
 		return value, false
 	}
-	return q.head.value, true
+	return q.head.values[q.headIndex], true
+}
+
+// filter compacts the kept values toward the head, and releases the unused chunks.
+func (q *linkedQueue[T]) filter(keep func(T) bool) int {
+	if q.head == nil {
+		return 0
+	}
+	removed := 0
+	w, wi := q.head, q.headIndex
+	for elem := q.head; elem != nil; elem = elem.next {
+		start, end := q.bounds(elem)
+		for i := start; i < end; i++ {
+			value := elem.values[i]
+			if !keep(value) {
+				removed++
+				continue
+			}
+			if wi == elementSize {
+				w, wi = w.next, 0
+			}
+			w.values[wi] = value
+			wi++
+		}
+	}
+	_, end := q.bounds(w)
+	clear(w.values[wi:end])
+	for elem := w.next; elem != nil; {
+		next := elem.next
+		q.allocator.put(elem)
+		elem = next
+	}
+	w.next = nil
+	q.tail, q.tailIndex = w, wi
+	if q.head == q.tail && q.headIndex == q.tailIndex {
+		q.release()
+	}
+	return removed
+}
+
+func (q *linkedQueue[T]) appendTo(s []T) []T {
+	for elem := q.head; elem != nil; elem = elem.next {
+		start, end := q.bounds(elem)
+		s = append(s, elem.values[start:end]...)
+	}
+	return s
+}
+
+// bounds returns the range of the values used in a chunk.
+func (q *linkedQueue[T]) bounds(elem *Element[T]) (start, end int) {
+	end = elementSize
+	if elem == q.head {
+		start = q.headIndex
+	}
+	if elem == q.tail {
+		end = q.tailIndex
+	}
+	return start, end
+}
+
+// release releases the head chunk, which is the last one, and empties the queue.
+func (q *linkedQueue[T]) release() {
+	q.allocator.put(q.head)
+	q.head, q.headIndex = nil, 0
+	q.tail, q.tailIndex = nil, 0
+}
+
+func (q *linkedQueue[T]) flush() {}
+
+func (q *linkedQueue[T]) reset() {
+	q.head, q.headIndex = nil, 0
+	q.tail, q.tailIndex = nil, 0
 }
 
-func (q *queue[T]) reset() {
-	q.head = nil
-	q.tail = nil
+// elementSize is the number of values in an Element.
+const elementSize = 64
+
+// Element is a chunk of values of the queue.
+//
+// It is only exposed for WithAllocator, and its fields are managed by the Channel.
+type Element[T any] struct {
+	values [elementSize]T
+	next   *Element[T]
 }
 
-type queueElement[T any] struct {
-	value T
-	next  *queueElement[T]
+// allocator allocates the chunks of the queue.
+//
+// It uses the functions provided by WithAllocator, or a sync.Pool by default.
+// The chunks pre-allocated for WithInitialCapacity are used first.
+type allocator[T any] struct {
+	pool  sync.Pool
+	spare []*Element[T]
+	alloc func() *Element[T]
+	free  func(*Element[T])
+}
+
+func newAllocator[T any](o *options[T]) *allocator[T] {
+	a := &allocator[T]{
+		alloc: o.alloc,
+		free:  o.free,
+	}
+	if a.alloc == nil && o.initialCapacity > 0 {
+		elems := make([]Element[T], (o.initialCapacity+elementSize-1)/elementSize)
+		a.spare = make([]*Element[T], len(elems))
+		for i := range elems {
+			a.spare[i] = &elems[i]
+		}
+	}
+	return a
+}
+
+func (a *allocator[T]) get() *Element[T] {
+	if a.alloc != nil {
+		return a.alloc()
+	}
+	if n := len(a.spare); n > 0 {
+		elem := a.spare[n-1]
+		a.spare[n-1] = nil
+		a.spare = a.spare[:n-1]
+		return elem
+	}
+	elemItf := a.pool.Get()
+	if elemItf != nil {
+		return elemItf.(*Element[T]) //nolint:forcetypeassert // The pool only contains *Element[T].
+	}
+	return &Element[T]{}
+}
+
+func (a *allocator[T]) put(elem *Element[T]) {
+	*elem = Element[T]{}
+	if a.free != nil {
+		a.free(elem)
+		return
+	}
+	a.pool.Put(elem)
 }
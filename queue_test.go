@@ -0,0 +1,156 @@
+package unlimitedchannel
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func newTestLinkedQueue() *linkedQueue[int] {
+	return &linkedQueue[int]{
+		allocator: newAllocator(new(options[int])),
+	}
+}
+
+func TestLinkedQueue(t *testing.T) {
+	q := newTestLinkedQueue()
+	count := elementSize*3 + 10
+	for i := 0; i < count; i++ {
+		q.enqueue(i)
+	}
+	v, ok := q.pick()
+	assert.True(t, ok)
+	assert.Equal(t, v, 0)
+	assert.SliceLen(t, q.appendTo(nil), count)
+	for i := 0; i < count; i++ {
+		v, ok = q.dequeue()
+		assert.True(t, ok)
+		assert.Equal(t, v, i)
+	}
+	_, ok = q.dequeue()
+	assert.False(t, ok)
+	assert.Zero(t, q.head)
+	assert.Zero(t, q.tail)
+}
+
+func TestLinkedQueueFilter(t *testing.T) {
+	q := newTestLinkedQueue()
+	count := elementSize*3 + 10
+	for i := 0; i < count; i++ {
+		q.enqueue(i)
+	}
+	q.dequeue()
+	removed := q.filter(func(v int) bool {
+		return v%3 == 0
+	})
+	var expected []int
+	for i := 3; i < count; i += 3 {
+		expected = append(expected, i)
+	}
+	assert.Equal(t, removed, count-1-len(expected))
+	assert.DeepEqual(t, q.appendTo(nil), expected)
+	q.enqueue(count)
+	expected = append(expected, count)
+	for _, e := range expected {
+		v, _ := q.dequeue()
+		assert.Equal(t, v, e)
+	}
+	removed = q.filter(func(v int) bool {
+		return false
+	})
+	assert.Equal(t, removed, 0)
+	q.enqueue(1)
+	removed = q.filter(func(v int) bool {
+		return false
+	})
+	assert.Equal(t, removed, 1)
+	_, ok := q.pick()
+	assert.False(t, ok)
+	assert.Zero(t, q.head)
+}
+
+func BenchmarkQueue(b *testing.B) {
+	for _, impl := range []struct {
+		name string
+		new  func() queue[int]
+	}{
+		{
+			name: "Linked",
+			new: func() queue[int] {
+				return newTestLinkedQueue()
+			},
+		},
+		{
+			name: "Ring",
+			new: func() queue[int] {
+				return newRingQueue[int](0)
+			},
+		},
+	} {
+		b.Run(impl.name, func(b *testing.B) {
+			for _, count := range []int{1, 100, 10000} {
+				b.Run(strconv.Itoa(count), func(b *testing.B) {
+					q := impl.new()
+					b.ReportAllocs()
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						for j := 0; j < count; j++ {
+							q.enqueue(j)
+						}
+						for j := 0; j < count; j++ {
+							q.dequeue()
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkWithInitialCapacity(b *testing.B) {
+	for _, impl := range []struct {
+		name string
+		impl QueueImpl
+	}{
+		{
+			name: "Linked",
+			impl: QueueImplLinked,
+		},
+		{
+			name: "Ring",
+			impl: QueueImplRing,
+		},
+	} {
+		b.Run(impl.name, func(b *testing.B) {
+			for _, initialCapacity := range []int{0, 10000} {
+				b.Run(strconv.Itoa(initialCapacity), func(b *testing.B) {
+					o := newOptions([]Option[int]{
+						WithQueueImpl[int](impl.impl),
+						WithInitialCapacity[int](initialCapacity),
+					})
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						b.StopTimer()
+						q := newQueue(&o)
+						b.StartTimer()
+						for j := 0; j < 10000; j++ {
+							q.enqueue(j)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestWithInitialCapacity(t *testing.T) {
+	o := newOptions([]Option[int]{WithInitialCapacity[int](elementSize*2 + 1)})
+	q := newQueue(&o).(*linkedQueue[int]) //nolint:forcetypeassert // It is always a linked queue.
+	assert.SliceLen(t, q.allocator.spare, 3)
+	for i := 0; i < elementSize*3; i++ {
+		q.enqueue(i)
+	}
+	assert.SliceLen(t, q.allocator.spare, 0)
+	assert.SliceLen(t, q.appendTo(nil), elementSize*3)
+}
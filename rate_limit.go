@@ -0,0 +1,76 @@
+package unlimitedchannel
+
+import (
+	"math"
+	"time"
+)
+
+// WithOutputRateLimit limits the rate of the values sent to the output channel.
+//
+// It uses a token bucket, like golang.org/x/time/rate: the rate r is the number of values per second, and burst is the maximum number of values that can be sent at once.
+// The worker waits for a token before sending each value, and the queue absorbs the backlog.
+// It doesn't block the worker: it keeps receiving values, and it stops immediately when the channel is closed (see WithCloseMode).
+// The values already buffered in the output channel can be received at once.
+// It uses the Clock set by WithClock.
+// A zero or negative rate disables it.
+func WithOutputRateLimit[T any](r float64, burst int) Option[T] {
+	return func(o *options[T]) {
+		o.outputRate = r
+		o.outputBurst = max(burst, 1)
+	}
+}
+
+// refillRateTokens adds the tokens earned since the last refill, see WithOutputRateLimit.
+func (c *Channel[T]) refillRateTokens() {
+	if c.options.outputRate <= 0 {
+		return
+	}
+	now := c.options.getClock().Now()
+	if c.rateLast.IsZero() {
+		c.rateTokens = float64(c.options.outputBurst)
+	} else {
+		c.rateTokens += now.Sub(c.rateLast).Seconds() * c.options.outputRate
+		c.rateTokens = min(c.rateTokens, float64(c.options.outputBurst))
+	}
+	c.rateLast = now
+}
+
+// rateAllowed returns true if a token is available, see WithOutputRateLimit.
+func (c *Channel[T]) rateAllowed() bool {
+	return c.options.outputRate <= 0 || c.rateTokens >= 1
+}
+
+// consumeRateToken consumes a token after a value is sent.
+func (c *Channel[T]) consumeRateToken() {
+	if c.options.outputRate > 0 {
+		c.rateTokens--
+	}
+}
+
+// rateLimitC returns the channel of the timer that waits for the next token, or a nil channel if it is not needed.
+//
+// It starts or stops the timer, depending on the tokens and the number of values.
+func (c *Channel[T]) rateLimitC() <-chan time.Time {
+	if c.rateAllowed() || c.queueLen() == 0 {
+		if c.rateTimerActive {
+			c.rateTimer.Stop()
+			c.rateTimerActive = false
+		}
+		return nil
+	}
+	if !c.rateTimerActive {
+		// Rounding up ensures that the token is available when the timer fires.
+		d := time.Duration(math.Ceil((1 - c.rateTokens) / c.options.outputRate * float64(time.Second)))
+		if c.rateTimer == nil {
+			c.rateTimer = c.options.getClock().NewTimer(d)
+		} else {
+			c.rateTimer.Reset(d)
+		}
+		c.rateTimerActive = true
+	}
+	return c.rateTimer.C()
+}
+
+func (c *Channel[T]) onRateLimitTimer() {
+	c.rateTimerActive = false
+}
@@ -0,0 +1,72 @@
+package unlimitedchannel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithOutputRateLimit(t *testing.T) {
+	clock := newFakeClock()
+	c := New(
+		WithOutputRateLimit[int](10, 2),
+		WithClock[int](clock),
+	)
+	in := c.In()
+	for i := 0; i < 10; i++ {
+		in <- i
+	}
+	waitFor(t, func() bool {
+		return len(c.out) == 2 && c.queueLen() == 8 && clock.activeTimers() == 1
+	})
+	for i := 3; i <= 10; i++ {
+		clock.Advance(100 * time.Millisecond)
+		waitFor(t, func() bool {
+			return len(c.out) == i && (i == 10 || clock.activeTimers() == 1)
+		})
+		assert.Equal(t, c.queueLen(), 10-i)
+	}
+	out := c.Out()
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, <-out, i)
+	}
+	c.Close()
+}
+
+func TestWithOutputRateLimitBurst(t *testing.T) {
+	clock := newFakeClock()
+	c := New(
+		WithOutputRateLimit[int](10, 3),
+		WithClock[int](clock),
+	)
+	in := c.In()
+	out := c.Out()
+	in <- 0
+	assert.Equal(t, <-out, 0)
+	waitFor(t, func() bool {
+		return c.Stats().TotalDequeued == 1
+	})
+	clock.Advance(time.Hour)
+	for i := 1; i < 10; i++ {
+		in <- i
+	}
+	waitFor(t, func() bool {
+		return len(c.out) == 3 && c.queueLen() == 6
+	})
+	c.Close()
+}
+
+func TestWithOutputRateLimitClose(t *testing.T) {
+	c := New(WithOutputRateLimit[int](1, 1))
+	in := c.In()
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+	c.Close()
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+}
@@ -0,0 +1,114 @@
+package unlimitedchannel
+
+import (
+	"context"
+	"reflect"
+	"slices"
+)
+
+// RecvAny receives a value from any of the Channels, and returns it with the index of the Channel that produced it.
+//
+// If several Channels have a value ready, the one with the lowest index is chosen.
+// The closed Channels are skipped.
+// It returns ok=false when all the Channels are closed, or when the context is canceled (see ctx.Err()), and the index is -1.
+// It doesn't use reflection for up to 4 Channels.
+func RecvAny[T any](ctx context.Context, cs ...*Channel[T]) (value T, index int, ok bool) {
+	outs := make([]<-chan T, len(cs))
+	for i, c := range cs {
+		outs[i] = c.Out()
+	}
+	for {
+		value, index, ok = tryRecvAny(outs)
+		if ok {
+			return value, index, true
+		}
+		if !slices.ContainsFunc(outs, isOpen) {
+			return value, -1, false
+		}
+		if len(outs) <= recvAnyMaxDirect {
+			value, index, ok = recvAnyDirect(ctx, outs)
+		} else {
+			value, index, ok = recvAnyReflect(ctx, outs)
+		}
+		if ok || index < 0 {
+			return value, index, ok
+		}
+		// The channel is closed.
+		outs[index] = nil
+	}
+}
+
+// recvAnyMaxDirect is the maximum number of channels handled by recvAnyDirect.
+const recvAnyMaxDirect = 4
+
+func isOpen[T any](out <-chan T) bool {
+	return out != nil
+}
+
+// tryRecvAny receives a value from the first channel that has one, without blocking.
+//
+// The closed channels are set to nil.
+func tryRecvAny[T any](outs []<-chan T) (T, int, bool) {
+	for i, out := range outs {
+		if out == nil {
+			continue
+		}
+		select {
+		case v, ok := <-out:
+			if ok {
+				return v, i, true
+			}
+			outs[i] = nil
+		default:
+		}
+	}
+	var zero T
+	return zero, -1, false
+}
+
+// recvAnyDirect waits for a value from up to recvAnyMaxDirect channels, with a select statement.
+//
+// If a channel is closed, it returns its index with ok=false.
+// If the context is canceled, it returns the index -1.
+func recvAnyDirect[T any](ctx context.Context, outs []<-chan T) (value T, index int, ok bool) {
+	// A nil channel is never selected.
+	var os [recvAnyMaxDirect]<-chan T
+	copy(os[:], outs)
+	select {
+	case value, ok = <-os[0]:
+		return value, 0, ok
+	case value, ok = <-os[1]:
+		return value, 1, ok
+	case value, ok = <-os[2]:
+		return value, 2, ok
+	case value, ok = <-os[3]:
+		return value, 3, ok
+	case <-ctx.Done():
+		return value, -1, false
+	}
+}
+
+// recvAnyReflect is like recvAnyDirect, but it supports any number of channels, with reflect.Select.
+func recvAnyReflect[T any](ctx context.Context, outs []<-chan T) (value T, index int, ok bool) {
+	cases := make([]reflect.SelectCase, 0, len(outs)+1)
+	cases = append(cases, reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(ctx.Done()),
+	})
+	for _, out := range outs {
+		sc := reflect.SelectCase{
+			Dir: reflect.SelectRecv,
+		}
+		if out != nil {
+			sc.Chan = reflect.ValueOf(out)
+		}
+		cases = append(cases, sc)
+	}
+	chosen, v, ok := reflect.Select(cases)
+	if chosen == 0 {
+		return value, -1, false
+	}
+	// The assertion only fails for a nil interface value, which is the zero value.
+	value, _ = v.Interface().(T)
+	return value, chosen - 1, ok
+}
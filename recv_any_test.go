@@ -0,0 +1,105 @@
+package unlimitedchannel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestRecvAny(t *testing.T) {
+	ctx := context.Background()
+	c1 := New[int]()
+	c2 := New[int]()
+	c2.In() <- 1
+	v, i, ok := RecvAny(ctx, c1, c2)
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+	assert.Equal(t, i, 1)
+	c1.In() <- 2
+	v, i, ok = RecvAny(ctx, c1, c2)
+	assert.True(t, ok)
+	assert.Equal(t, v, 2)
+	assert.Equal(t, i, 0)
+	c1.Close()
+	c2.Close()
+	_, i, ok = RecvAny(ctx, c1, c2)
+	assert.False(t, ok)
+	assert.Equal(t, i, -1)
+}
+
+func TestRecvAnyOrdered(t *testing.T) {
+	c1 := New[int]()
+	c2 := New[int]()
+	c2.In() <- 2
+	c1.In() <- 1
+	waitFor(t, func() bool {
+		return len(c1.out) == 1 && len(c2.out) == 1
+	})
+	v, i, ok := RecvAny(context.Background(), c1, c2)
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+	assert.Equal(t, i, 0)
+	c1.Close()
+	c2.Close()
+}
+
+func TestRecvAnyClosed(t *testing.T) {
+	c1 := New[int]()
+	c2 := New(WithSendAllOnClose[int](true))
+	c1.Close()
+	go func() {
+		c2.In() <- 1
+		c2.Close()
+	}()
+	v, i, ok := RecvAny(context.Background(), c1, c2)
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+	assert.Equal(t, i, 1)
+}
+
+func TestRecvAnyReflect(t *testing.T) {
+	cs := make([]*Channel[int], 6)
+	for i := range cs {
+		cs[i] = New(WithSendAllOnClose[int](true))
+	}
+	go func() {
+		cs[5].In() <- 5
+		for _, c := range cs {
+			c.Close()
+		}
+	}()
+	v, i, ok := RecvAny(context.Background(), cs...)
+	assert.True(t, ok)
+	assert.Equal(t, v, 5)
+	assert.Equal(t, i, 5)
+	_, _, ok = RecvAny(context.Background(), cs...)
+	assert.False(t, ok)
+}
+
+func TestRecvAnyContextCanceled(t *testing.T) {
+	c1 := New[int]()
+	c2 := New[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, i, ok := RecvAny(ctx, c1, c2)
+	assert.False(t, ok)
+	assert.Equal(t, i, -1)
+	c1.Close()
+	c2.Close()
+}
+
+func TestRecvAnyContextCanceledReflect(t *testing.T) {
+	cs := make([]*Channel[int], 6)
+	for i := range cs {
+		cs[i] = New[int]()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, i, ok := RecvAny(ctx, cs...)
+	assert.False(t, ok)
+	assert.Equal(t, i, -1)
+	for _, c := range cs {
+		c.Close()
+	}
+}
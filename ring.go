@@ -0,0 +1,136 @@
+package unlimitedchannel
+
+// QueueImpl is an implementation of the internal queue.
+type QueueImpl int
+
+const (
+	// QueueImplLinked is a linked list of chunks (default).
+	QueueImplLinked QueueImpl = iota
+	// QueueImplRing is a ring buffer backed by a slice, that grows and shrinks with the number of values.
+	QueueImplRing
+)
+
+// WithQueueImpl sets the implementation of the internal queue.
+//
+// WithAllocator only applies to QueueImplLinked.
+// It is ignored by NewLanes.
+func WithQueueImpl[T any](impl QueueImpl) Option[T] {
+	return func(o *options[T]) {
+		o.queueImpl = impl
+	}
+}
+
+// ringMinSize is the minimum size of the ring buffer, once it is allocated.
+const ringMinSize = 16
+
+// ringQueue is a ring buffer.
+//
+// It doubles its size when it is full, and halves it when it is less than a quarter full.
+type ringQueue[T any] struct {
+	buf     []T
+	head    int // Index of the first value.
+	len     int
+	minSize int
+}
+
+func newRingQueue[T any](initialCapacity int) *ringQueue[T] {
+	q := &ringQueue[T]{
+		minSize: max(initialCapacity, ringMinSize),
+	}
+	if initialCapacity > 0 {
+		q.buf = make([]T, initialCapacity)
+	}
+	return q
+}
+
+func (q *ringQueue[T]) enqueue(value T) {
+	if q.len == len(q.buf) {
+		q.resize(max(len(q.buf)*2, q.minSize))
+	}
+	q.buf[q.index(q.len)] = value
+	q.len++
+}
+
+func (q *ringQueue[T]) dequeue() (T, bool) {
+	var zero T
+	if q.len == 0 {
+		return zero, false
+	}
+	value := q.buf[q.head]
+	q.buf[q.head] = zero
+	q.head = q.index(1)
+	q.len--
+	q.shrink()
+	return value, true
+}
+
+func (q *ringQueue[T]) pick() (T, bool) {
+	if q.len == 0 {
+		var zero T
+		return zero, false
+	}
+	return q.buf[q.head], true
+}
+
+func (q *ringQueue[T]) filter(keep func(T) bool) int {
+	var zero T
+	n := 0
+	for i := 0; i < q.len; i++ {
+		value := q.buf[q.index(i)]
+		if keep(value) {
+			q.buf[q.index(n)] = value
+			n++
+		}
+	}
+	for i := n; i < q.len; i++ {
+		q.buf[q.index(i)] = zero
+	}
+	removed := q.len - n
+	q.len = n
+	q.shrink()
+	return removed
+}
+
+func (q *ringQueue[T]) appendTo(s []T) []T {
+	end := q.head + q.len
+	if end <= len(q.buf) {
+		return append(s, q.buf[q.head:end]...)
+	}
+	s = append(s, q.buf[q.head:]...)
+	return append(s, q.buf[:end-len(q.buf)]...)
+}
+
+func (q *ringQueue[T]) flush() {}
+
+func (q *ringQueue[T]) reset() {
+	q.buf = nil
+	q.head = 0
+	q.len = 0
+}
+
+// index returns the index in the buffer of the i-th value.
+func (q *ringQueue[T]) index(i int) int {
+	i += q.head
+	if i >= len(q.buf) {
+		i -= len(q.buf)
+	}
+	return i
+}
+
+func (q *ringQueue[T]) shrink() {
+	size := len(q.buf)
+	for size/2 >= q.minSize && q.len <= size/4 {
+		size /= 2
+	}
+	if size != len(q.buf) {
+		q.resize(size)
+	}
+}
+
+// resize moves the values to a new buffer of the given size, starting at index 0.
+func (q *ringQueue[T]) resize(size int) {
+	buf := make([]T, size)
+	q.appendTo(buf[:0])
+	q.buf = buf
+	q.head = 0
+}
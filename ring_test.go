@@ -0,0 +1,101 @@
+package unlimitedchannel
+
+import (
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithQueueImpl(t *testing.T) {
+	c := New(WithQueueImpl[int](QueueImplRing))
+	in := c.In()
+	out := c.Out()
+	count := 1000
+	for i := 0; i < count; i++ {
+		in <- i
+	}
+	for i := 0; i < count; i++ {
+		v := <-out
+		assert.Equal(t, v, i)
+	}
+	close(in)
+}
+
+func TestRingQueueWrapAround(t *testing.T) {
+	q := newRingQueue[int](0)
+	next := 0
+	for i := 0; i < 10; i++ {
+		q.enqueue(i)
+	}
+	for i := 0; i < 1000; i++ {
+		v, ok := q.dequeue()
+		assert.True(t, ok)
+		assert.Equal(t, v, next)
+		next++
+		q.enqueue(next + 9)
+	}
+	assert.Equal(t, len(q.buf), ringMinSize)
+	assert.SliceLen(t, q.appendTo(nil), 10)
+	for i := 0; i < 10; i++ {
+		v, _ := q.dequeue()
+		assert.Equal(t, v, next+i)
+	}
+}
+
+func TestRingQueueGrowShrink(t *testing.T) {
+	q := newRingQueue[int](0)
+	count := 1000
+	for i := 0; i < count; i++ {
+		q.enqueue(i)
+	}
+	assert.Equal(t, len(q.buf), 1024)
+	for i := 0; i < count-10; i++ {
+		v, _ := q.dequeue()
+		assert.Equal(t, v, i)
+	}
+	assert.Equal(t, len(q.buf), 32)
+	for _, v := range q.buf[q.index(q.len):] {
+		assert.Zero(t, v)
+	}
+	v, ok := q.pick()
+	assert.True(t, ok)
+	assert.Equal(t, v, count-10)
+	for i := count - 10; i < count; i++ {
+		v, _ = q.dequeue()
+		assert.Equal(t, v, i)
+	}
+	_, ok = q.dequeue()
+	assert.False(t, ok)
+	assert.Equal(t, len(q.buf), ringMinSize)
+}
+
+func TestRingQueueFilter(t *testing.T) {
+	q := newRingQueue[int](0)
+	for i := 0; i < 10; i++ {
+		q.enqueue(i)
+	}
+	for i := 0; i < 5; i++ {
+		q.dequeue()
+	}
+	for i := 10; i < 20; i++ {
+		q.enqueue(i)
+	}
+	removed := q.filter(func(v int) bool {
+		return v%2 == 0
+	})
+	assert.Equal(t, removed, 8)
+	assert.DeepEqual(t, q.appendTo(nil), []int{6, 8, 10, 12, 14, 16, 18})
+}
+
+func TestRingQueueInitialCapacity(t *testing.T) {
+	q := newRingQueue[int](100)
+	assert.Equal(t, len(q.buf), 100)
+	for i := 0; i < 300; i++ {
+		q.enqueue(i)
+	}
+	assert.Equal(t, len(q.buf), 400)
+	for i := 0; i < 300; i++ {
+		q.dequeue()
+	}
+	assert.Equal(t, len(q.buf), 100)
+}
@@ -0,0 +1,225 @@
+package unlimitedchannel
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/pierrre/go-libs/goroutine"
+)
+
+// SharedRunner runs the workers of several Channels in a few goroutines.
+//
+// It allows to reduce the number of goroutines when many Channels are used, at the cost of throughput: it uses reflect.Select.
+// Each goroutine runs up to 256 Channels, because reflect.Select is limited to 65536 cases, and its cost is proportional to the number of cases.
+// A goroutine is started when a Channel is added and all the goroutines are full, and it stops when all its Channels are stopped.
+// The functions called by the workers (e.g. WithOnDrop) block all the Channels of the same goroutine.
+// The zero value is ready to use.
+type SharedRunner struct {
+	mu     sync.Mutex
+	shards []*sharedShard
+}
+
+// sharedShardSize is the maximum number of workers run by a goroutine of a SharedRunner.
+//
+// A worker has less than 10 cases, so the number of cases of reflect.Select stays below its limit.
+const sharedShardSize = 256
+
+// sharedShard is a goroutine of a SharedRunner.
+type sharedShard struct {
+	runner  *SharedRunner
+	pending []sharedWorker
+	count   int // Number of workers, including the pending ones.
+	running bool
+	wake    chan struct{}
+}
+
+// WithRunner runs the worker of the Channel in a SharedRunner, instead of a dedicated goroutine.
+//
+// WithPprofLabels is ignored.
+func WithRunner[T any](r *SharedRunner) Option[T] {
+	return func(o *options[T]) {
+		o.runner = r
+	}
+}
+
+// sharedWorker is a worker that can be run by a SharedRunner.
+type sharedWorker interface {
+	isStopped() bool
+	prepare()
+	appendSharedCases(cases []sharedCase) []sharedCase
+	finish()
+}
+
+// sharedCase is a case of reflect.Select, with the function that handles it.
+type sharedCase struct {
+	reflect.SelectCase
+	handle func(v reflect.Value, ok bool)
+}
+
+func (r *SharedRunner) add(w sharedWorker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.availableShard()
+	s.pending = append(s.pending, w)
+	s.count++
+	if !s.running {
+		s.running = true
+		goroutine.Go(s.run)
+		return
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// availableShard returns a shard that can run another worker, and creates it if needed.
+func (r *SharedRunner) availableShard() *sharedShard {
+	for _, s := range r.shards {
+		if s.count < sharedShardSize {
+			return s
+		}
+	}
+	s := &sharedShard{
+		runner: r,
+		wake:   make(chan struct{}, 1),
+	}
+	r.shards = append(r.shards, s)
+	return s
+}
+
+// takePending returns the added workers, or false if the shard must stop because there is no worker.
+func (s *sharedShard) takePending(ws []sharedWorker) ([]sharedWorker, bool) {
+	s.runner.mu.Lock()
+	defer s.runner.mu.Unlock()
+	ws = append(ws, s.pending...)
+	clear(s.pending)
+	s.pending = s.pending[:0]
+	s.count = len(ws)
+	if len(ws) == 0 {
+		s.running = false
+		return ws, false
+	}
+	return ws, true
+}
+
+func (s *sharedShard) run() {
+	var ws []sharedWorker
+	var cases []sharedCase
+	var selectCases []reflect.SelectCase
+	for {
+		var ok bool
+		ws, ok = s.takePending(ws)
+		if !ok {
+			return
+		}
+		cases = append(cases[:0], sharedCase{
+			SelectCase: reflect.SelectCase{
+				Dir:  reflect.SelectRecv,
+				Chan: reflect.ValueOf(s.wake),
+			},
+			handle: func(reflect.Value, bool) {},
+		})
+		for _, w := range ws {
+			w.prepare()
+			cases = w.appendSharedCases(cases)
+		}
+		selectCases = selectCases[:0]
+		for _, sc := range cases {
+			selectCases = append(selectCases, sc.SelectCase)
+		}
+		chosen, v, recvOK := reflect.Select(selectCases)
+		cases[chosen].handle(v, recvOK)
+		ws = removeStoppedWorkers(ws)
+	}
+}
+
+// removeStoppedWorkers finishes and removes the stopped workers.
+func removeStoppedWorkers(ws []sharedWorker) []sharedWorker {
+	n := 0
+	for _, w := range ws {
+		if w.isStopped() {
+			w.finish()
+			continue
+		}
+		ws[n] = w
+		n++
+	}
+	clear(ws[n:])
+	return ws[:n]
+}
+
+// appendSharedCases appends the cases of the events of the worker.
+//
+// It is equivalent to step, for a SharedRunner.
+func (c *Channel[T]) appendSharedCases(cases []sharedCase) []sharedCase {
+	if c.canReceive() {
+		cases = appendRecvCase(cases, c.in, c.onReceive)
+	}
+	if out, outValue := c.nextOut(); out != nil {
+		cases = appendSendCase(cases, out, outValue, c.dequeue)
+	}
+	if epochs, epoch := c.nextEpoch(); epochs != nil {
+		cases = appendSendCase(cases, epochs, epoch, c.epochSent)
+	}
+	cases = appendRecvCase(cases, c.ctrl, func(f func(), _ bool) {
+		f()
+	})
+	if snapshotC := c.snapshotC(); snapshotC != nil {
+		cases = appendRecvCase(cases, snapshotC, func(time.Time, bool) {
+			c.snapshot()
+		})
+	}
+	if c.releaseDone != nil {
+		cases = appendRecvCase(cases, c.releaseDone, func(struct{}, bool) {
+			c.releaseDone = nil
+			c.onReleased()
+		})
+	}
+	if softLimitC := c.softLimitC(); softLimitC != nil {
+		cases = appendRecvCase(cases, softLimitC, func(time.Time, bool) {
+			c.onSoftLimitTimeout()
+		})
+	}
+	if rateLimitC := c.rateLimitC(); rateLimitC != nil {
+		cases = appendRecvCase(cases, rateLimitC, func(time.Time, bool) {
+			c.onRateLimitTimer()
+		})
+	}
+	if ttlC := c.ttlC(); ttlC != nil {
+		cases = appendRecvCase(cases, ttlC, func(time.Time, bool) {
+			c.onTTLTimer()
+		})
+	}
+	return cases
+}
+
+func appendRecvCase[V any](cases []sharedCase, ch <-chan V, handle func(v V, ok bool)) []sharedCase {
+	return append(cases, sharedCase{
+		SelectCase: reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(ch),
+		},
+		handle: func(rv reflect.Value, ok bool) {
+			var v V
+			if ok {
+				v, _ = rv.Interface().(V)
+			}
+			handle(v, ok)
+		},
+	})
+}
+
+func appendSendCase[V any](cases []sharedCase, ch chan<- V, v V, handle func()) []sharedCase {
+	return append(cases, sharedCase{
+		SelectCase: reflect.SelectCase{
+			Dir:  reflect.SelectSend,
+			Chan: reflect.ValueOf(ch),
+			Send: reflect.ValueOf(&v).Elem(),
+		},
+		handle: func(reflect.Value, bool) {
+			handle()
+		},
+	})
+}
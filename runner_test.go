@@ -0,0 +1,128 @@
+package unlimitedchannel
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithRunner(t *testing.T) {
+	r := new(SharedRunner)
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 20; i++ {
+		c := New(WithRunner[int](r), WithSendAllOnClose[int](true))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			in := c.In()
+			for j := 0; j < 100; j++ {
+				in <- j
+			}
+			c.Close()
+			j := 0
+			for v := range c.Out() {
+				assert.Equal(t, v, j)
+				j++
+			}
+			assert.Equal(t, j, 100)
+			<-c.Done()
+		}()
+	}
+	wg.Wait()
+	waitRunnerStopped(t, r)
+}
+
+func TestWithRunnerMany(t *testing.T) {
+	r := new(SharedRunner)
+	// Without shards, reflect.Select would panic, because each Channel has 2 cases (input and control).
+	cs := make([]*Channel[int], 33000)
+	for i := range cs {
+		cs[i] = New(WithRunner[int](r))
+	}
+	for i, c := range cs {
+		if i%1000 == 0 {
+			c.In() <- i
+			assert.Equal(t, <-c.Out(), i)
+		}
+	}
+	for _, c := range cs {
+		c.Close()
+	}
+	for _, c := range cs {
+		<-c.Done()
+	}
+	r.mu.Lock()
+	assert.SliceLen(t, r.shards, (len(cs)+sharedShardSize-1)/sharedShardSize)
+	r.mu.Unlock()
+	waitRunnerStopped(t, r)
+}
+
+func TestWithRunnerControl(t *testing.T) {
+	r := new(SharedRunner)
+	c := New(WithRunner[int](r))
+	in := c.In()
+	in <- 1
+	in <- 2
+	assert.Equal(t, c.Drain(), 2)
+	assert.Equal(t, c.Len(), 0)
+	c.Close()
+	<-c.Done()
+}
+
+func waitRunnerStopped(tb testing.TB, r *SharedRunner) {
+	tb.Helper()
+	waitFor(tb, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for _, s := range r.shards {
+			if s.running {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func BenchmarkWithRunner(b *testing.B) {
+	for _, tc := range []struct {
+		name string
+		opts func() []Option[int]
+	}{
+		{
+			name: "Dedicated",
+			opts: func() []Option[int] {
+				return nil
+			},
+		},
+		{
+			name: "Shared",
+			opts: func() []Option[int] {
+				return []Option[int]{WithRunner[int](new(SharedRunner))}
+			},
+		},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			opts := tc.opts()
+			before := runtime.NumGoroutine()
+			cs := make([]*Channel[int], 1000)
+			for i := range cs {
+				cs[i] = New(opts...)
+			}
+			goroutines := runtime.NumGoroutine() - before
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c := cs[i%len(cs)]
+				c.In() <- i
+				<-c.Out()
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(goroutines), "goroutines")
+			for _, c := range cs {
+				c.Close()
+				<-c.Done()
+			}
+		})
+	}
+}
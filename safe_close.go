@@ -0,0 +1,72 @@
+package unlimitedchannel
+
+import (
+	"errors"
+)
+
+// WithConcurrentSafeClose serializes Close with the sends of the methods of the Channel, so they never panic with "send on closed channel".
+//
+// Close stops the pending sends, and waits until they return, before closing the input channel.
+// Then, Send returns ErrClosed, SendBatch discards the values, and SendWithAck calls nack.
+// The sends on the input channel returned by In() are not protected, so the producers must use the methods.
+// The input channel must only be closed by Close.
+func WithConcurrentSafeClose[T any](enabled bool) Option[T] {
+	return func(o *options[T]) {
+		o.safeClose = enabled
+	}
+}
+
+// ErrClosed is returned by Channel.Send if the channel is closed, see WithConcurrentSafeClose.
+var ErrClosed = errors.New("channel closed")
+
+// lockSend prevents the input channel from being closed during a send, see WithConcurrentSafeClose.
+//
+// It returns false if the channel is closing, otherwise unlockSend must be called after the send.
+func (c *Channel[T]) lockSend() bool {
+	if !c.options.safeClose {
+		return true
+	}
+	c.closeMu.RLock()
+	select {
+	case <-c.closing:
+		c.closeMu.RUnlock()
+		return false
+	default:
+		return true
+	}
+}
+
+func (c *Channel[T]) unlockSend() {
+	if c.options.safeClose {
+		c.closeMu.RUnlock()
+	}
+}
+
+// closeIn closes the input channel, after the pending sends are stopped, see WithConcurrentSafeClose.
+func (c *Channel[T]) closeIn() {
+	if c.options.safeClose {
+		close(c.closing)
+		c.closeMu.Lock()
+		defer c.closeMu.Unlock()
+	}
+	close(c.in)
+}
+
+// sendIn sends a value to the input channel, and returns false if the channel is closing, or if the worker stopped receiving (see stopInput).
+func (c *Channel[T]) sendIn(v T) bool {
+	if !c.lockSend() {
+		return false
+	}
+	defer c.unlockSend()
+	if c.isInStopped() {
+		return false
+	}
+	select {
+	case c.in <- v:
+		return true
+	case <-c.closing:
+		return false
+	case <-c.inStopped:
+		return false
+	}
+}
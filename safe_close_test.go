@@ -0,0 +1,51 @@
+package unlimitedchannel
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithConcurrentSafeClose(t *testing.T) {
+	for n := 0; n < 20; n++ {
+		c := New(WithConcurrentSafeClose[int](true), WithMaxCapacity[int](10))
+		ctx := context.Background()
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; ; j++ {
+					var err error
+					switch j % 3 {
+					case 0:
+						err = c.Send(ctx, i)
+					case 1:
+						c.SendBatch([]int{i, i})
+					case 2:
+						c.SendWithAck(i, nil, nil)
+					}
+					if err != nil {
+						assert.ErrorIs(t, err, ErrClosed)
+						return
+					}
+					if c.Closed() {
+						return
+					}
+				}
+			}()
+		}
+		go func() {
+			for range c.Out() {
+			}
+		}()
+		c.Close()
+		wg.Wait()
+		assert.ErrorIs(t, c.Send(ctx, 0), ErrClosed)
+		<-c.Done()
+		c.SendBatch([]int{0})
+		c.SendWithAck(0, nil, nil)
+	}
+}
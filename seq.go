@@ -0,0 +1,181 @@
+package unlimitedchannel
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+// WithSequenceNumbers enables the tracking of the sequence numbers, see Channel.All2.
+//
+// It has a cost for each value, so it is disabled by default.
+func WithSequenceNumbers[T any](enabled bool) Option[T] {
+	return func(o *options[T]) {
+		o.seqNumbers = enabled
+	}
+}
+
+// All2 returns an iterator over the values received from the output channel, with their sequence number, until it is closed.
+//
+// The sequence number is assigned when the value is received by the worker: it is the number of values received before.
+// So the values discarded by an eviction policy, the other options (see WithOnDrop), Filter or Drain leave gaps in the sequence.
+// The values must only be received by All2, otherwise the sequence numbers are wrong.
+// The sequence numbers must be enabled by WithSequenceNumbers, otherwise it panics.
+// They rely on the FIFO order of the queue, so it panics with WithPriority, WithCoalesceKey, WithConflate, WithWindowSort and NewLanes.
+// Breaking the loop stops receiving: the values that were not yielded stay in the channel, and can be received later.
+func (c *Channel[T]) All2() iter.Seq2[int, T] {
+	out := c.Out()
+	if !c.options.seqNumbers {
+		panic(c.describe() + ": sequence numbers are not enabled")
+	}
+	if !c.isFIFO() {
+		panic(c.describe() + ": sequence numbers on a channel that reorders the values")
+	}
+	return func(yield func(int, T) bool) {
+		for v := range out {
+			if !yield(c.receivedSeq(), v) {
+				return
+			}
+		}
+	}
+}
+
+// receivedSeq returns the sequence number of a value received from the output channel.
+func (c *Channel[T]) receivedSeq() int {
+	pos := c.outReceived.Add(1) - 1
+	c.outSeqsMu.Lock()
+	defer c.outSeqsMu.Unlock()
+	return int(c.outSeqs.at(pos))
+}
+
+// markReceivedOut is called when the worker removes values buffered in the output channel.
+func (c *Channel[T]) markReceivedOut(n int) {
+	if c.options.seqNumbers {
+		c.outReceived.Add(uint64(n))
+	}
+}
+
+// markEnqueued records the sequence number of the value added at the tail of the queue.
+func (c *Channel[T]) markEnqueued() {
+	if c.options.seqNumbers {
+		c.seqs.push(c.headPos+uint64(c.queueLen()), c.enqueued.Load()-1)
+	}
+}
+
+// markNextOut records the sequence number of the value at the head of the queue, which is the next value sent to the output channel.
+//
+// It must be called before the value is sent, because the consumer reads it after receiving the value.
+// The head can change before it is sent, so the sequence number of the position can be overwritten.
+func (c *Channel[T]) markNextOut() {
+	if !c.options.seqNumbers {
+		return
+	}
+	// The position of the value in the output channel is the number of values sent before.
+	pos := c.dequeued.Load()
+	seq := c.seqs.at(c.headPos)
+	if c.outSeqs.at(pos) == seq {
+		return
+	}
+	// The values that can still be received are buffered in the output channel.
+	// The consumer can also be computing the sequence number of the value received before.
+	minPos := uint64(0)
+	if n := uint64(cap(c.out)) + 1; pos > n {
+		minPos = pos - n
+	}
+	c.outSeqsMu.Lock()
+	defer c.outSeqsMu.Unlock()
+	c.outSeqs.truncate(pos)
+	c.outSeqs.push(pos, seq)
+	c.outSeqs.prune(minPos)
+}
+
+// seqTracker maps the positions to the sequence numbers.
+//
+// The sequence numbers are increasing, and most of the time, they are contiguous.
+// So it only stores a mark when the offset between the position and the sequence number changes.
+type seqTracker struct {
+	marks []seqMark
+}
+
+// seqMark indicates that the sequence number of the values at pos and after is their position plus offset.
+type seqMark struct {
+	pos    uint64
+	offset uint64
+}
+
+// fits returns true if the sequence number of the position doesn't require a new mark.
+func (t *seqTracker) fits(pos, seq uint64) bool {
+	offset := uint64(0)
+	if n := len(t.marks); n > 0 {
+		offset = t.marks[n-1].offset
+	}
+	return seq-pos == offset
+}
+
+// push records the sequence number of a position, which must be after the positions already recorded.
+func (t *seqTracker) push(pos, seq uint64) {
+	if !t.fits(pos, seq) {
+		t.marks = append(t.marks, seqMark{
+			pos:    pos,
+			offset: seq - pos,
+		})
+	}
+}
+
+// at returns the sequence number of a position.
+func (t *seqTracker) at(pos uint64) uint64 {
+	i, found := slices.BinarySearchFunc(t.marks, pos, func(m seqMark, pos uint64) int {
+		return cmp.Compare(m.pos, pos)
+	})
+	if !found {
+		i--
+	}
+	if i < 0 {
+		return pos
+	}
+	return pos + t.marks[i].offset
+}
+
+// prune removes the marks that are not needed for the positions from pos.
+func (t *seqTracker) prune(pos uint64) {
+	i := 0
+	for i+1 < len(t.marks) && t.marks[i+1].pos <= pos {
+		i++
+	}
+	if i > 0 {
+		t.marks = slices.Delete(t.marks, 0, i)
+	}
+}
+
+// truncate removes the marks from pos.
+func (t *seqTracker) truncate(pos uint64) {
+	n := len(t.marks)
+	for n > 0 && t.marks[n-1].pos >= pos {
+		n--
+	}
+	t.marks = t.marks[:n]
+}
+
+func (t *seqTracker) reset() {
+	t.marks = t.marks[:0]
+}
+
+// seqFilter updates the sequence numbers when the queue is filtered.
+//
+// next must be called for each value of the queue, in order.
+type seqFilter struct {
+	seqs    *seqTracker
+	pos     uint64
+	removed uint64
+	kept    seqTracker
+}
+
+func (f *seqFilter) next(keep bool) {
+	if keep {
+		// The kept values are moved toward the head.
+		f.kept.push(f.pos-f.removed, f.seqs.at(f.pos))
+	} else {
+		f.removed++
+	}
+	f.pos++
+}
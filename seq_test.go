@@ -0,0 +1,148 @@
+package unlimitedchannel
+
+import (
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+type testSeqValue struct {
+	seq   int
+	value int
+}
+
+func collectAll2(c *Channel[int]) []testSeqValue {
+	var res []testSeqValue
+	for seq, v := range c.All2() {
+		res = append(res, testSeqValue{seq: seq, value: v})
+	}
+	return res
+}
+
+func TestAll2(t *testing.T) {
+	c := New(WithSequenceNumbers[int](true), WithSendAllOnClose[int](true))
+	in := c.In()
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+	c.Close()
+	res := collectAll2(c)
+	assert.SliceLen(t, res, 100)
+	for i, sv := range res {
+		assert.Equal(t, sv.seq, i)
+		assert.Equal(t, sv.value, i)
+	}
+}
+
+func TestAll2Gaps(t *testing.T) {
+	c := New(
+		WithSequenceNumbers[int](true),
+		WithMaxCapacity[int](3),
+		WithDropNewest[int](true),
+		WithStartPaused[int](true),
+		WithSendAllOnClose[int](true),
+	)
+	in := c.In()
+	for i := 0; i < 5; i++ {
+		in <- i * 10
+	}
+	c.Resume()
+	var res []testSeqValue
+	for seq, v := range c.All2() {
+		res = append(res, testSeqValue{seq: seq, value: v})
+		if v == 20 {
+			in <- 50
+			in <- 60
+			c.Close()
+		}
+	}
+	assert.SliceEqual(t, res, []testSeqValue{
+		{seq: 0, value: 0},
+		{seq: 1, value: 10},
+		{seq: 2, value: 20},
+		{seq: 5, value: 50},
+		{seq: 6, value: 60},
+	})
+}
+
+func TestAll2DropOldest(t *testing.T) {
+	c := New(
+		WithSequenceNumbers[int](true),
+		WithMaxCapacity[int](2),
+		WithDropOldest[int](true),
+		WithStartPaused[int](true),
+		WithSendAllOnClose[int](true),
+	)
+	in := c.In()
+	for i := 0; i < 5; i++ {
+		in <- i
+	}
+	c.Close()
+	c.Resume()
+	res := collectAll2(c)
+	assert.SliceEqual(t, res, []testSeqValue{
+		{seq: 3, value: 3},
+		{seq: 4, value: 4},
+	})
+}
+
+func TestAll2Filter(t *testing.T) {
+	c := New(WithSequenceNumbers[int](true), WithStartPaused[int](true), WithSendAllOnClose[int](true))
+	in := c.In()
+	for i := 0; i < 10; i++ {
+		in <- i
+	}
+	waitQueueLen(t, c, 10)
+	c.Filter(func(v int) bool {
+		return v%3 == 0
+	})
+	in <- 10
+	c.Close()
+	c.Resume()
+	res := collectAll2(c)
+	assert.SliceEqual(t, res, []testSeqValue{
+		{seq: 0, value: 0},
+		{seq: 3, value: 3},
+		{seq: 6, value: 6},
+		{seq: 9, value: 9},
+		{seq: 10, value: 10},
+	})
+}
+
+func TestAll2Break(t *testing.T) {
+	c := New(WithSequenceNumbers[int](true), WithSendAllOnClose[int](true))
+	in := c.In()
+	for i := 0; i < 20; i++ {
+		in <- i
+	}
+	c.Close()
+	for seq, v := range c.All2() {
+		assert.Equal(t, seq, v)
+		if v == 3 {
+			break
+		}
+	}
+	res := collectAll2(c)
+	assert.SliceLen(t, res, 16)
+	for _, sv := range res {
+		assert.Equal(t, sv.seq, sv.value)
+	}
+}
+
+func TestAll2NotFIFO(t *testing.T) {
+	c := New(WithPriority(func(v int) int {
+		return v
+	}), WithSequenceNumbers[int](true))
+	defer c.Close()
+	assert.Panics(t, func() {
+		c.All2()
+	})
+}
+
+func TestAll2NotEnabled(t *testing.T) {
+	c := New[int]()
+	defer c.Close()
+	assert.Panics(t, func() {
+		c.All2()
+	})
+}
@@ -0,0 +1,56 @@
+package unlimitedchannel
+
+import (
+	"time"
+)
+
+// WithSoftLimit limits the number of values held by the channel, and discards the values if the limit is exceeded for too long.
+//
+// When the limit is reached, the worker stops receiving from the input channel, so sends block.
+// If no value is received from the output channel before the timeout, the worker starts discarding the values sent to the input channel, until the number of values goes below the limit.
+// It absorbs short bursts, and protects the memory during a sustained overload.
+// The discarded values are counted by DropCount, and passed to the function of WithOnDrop.
+// In order to respect the limit, the input and output channels are unbuffered.
+// The timeout uses the Clock set by WithClock.
+// A zero or negative limit disables it.
+func WithSoftLimit[T any](n int, timeout time.Duration) Option[T] {
+	return func(o *options[T]) {
+		o.softLimit = n
+		o.softLimitTimeout = timeout
+	}
+}
+
+func (c *Channel[T]) softLimitReached() bool {
+	return c.options.softLimit > 0 && c.queueLen() >= c.options.softLimit
+}
+
+// softLimitC returns the channel of the timer that starts discarding the values, or a nil channel if it is not needed.
+//
+// It starts or stops the timer, depending on the number of values.
+func (c *Channel[T]) softLimitC() <-chan time.Time {
+	if !c.softLimitReached() {
+		c.shedding = false
+		if c.softLimitTimerActive {
+			c.softLimitTimer.Stop()
+			c.softLimitTimerActive = false
+		}
+		return nil
+	}
+	if c.shedding {
+		return nil
+	}
+	if !c.softLimitTimerActive {
+		if c.softLimitTimer == nil {
+			c.softLimitTimer = c.options.getClock().NewTimer(c.options.softLimitTimeout)
+		} else {
+			c.softLimitTimer.Reset(c.options.softLimitTimeout)
+		}
+		c.softLimitTimerActive = true
+	}
+	return c.softLimitTimer.C()
+}
+
+func (c *Channel[T]) onSoftLimitTimeout() {
+	c.softLimitTimerActive = false
+	c.shedding = true
+}
@@ -0,0 +1,74 @@
+package unlimitedchannel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+)
+
+func newTestSoftLimitChannel(tb testing.TB) (*Channel[int], *fakeClock) {
+	tb.Helper()
+	clock := newFakeClock()
+	c := New(
+		WithSoftLimit[int](5, time.Second),
+		WithClock[int](clock),
+	)
+	in := c.In()
+	for i := 0; i < 5; i++ {
+		in <- i
+	}
+	waitFor(tb, func() bool {
+		return clock.activeTimers() == 1
+	})
+	return c, clock
+}
+
+func TestWithSoftLimitUnder(t *testing.T) {
+	c, _ := newTestSoftLimitChannel(t)
+	assert.Equal(t, c.Len(), 5)
+	assert.Equal(t, c.DropCount(), 0)
+	c.Close()
+}
+
+func TestWithSoftLimitBurst(t *testing.T) {
+	c, _ := newTestSoftLimitChannel(t)
+	in := c.In()
+	out := c.Out()
+	sent := make(chan struct{})
+	go func() {
+		in <- 5
+		close(sent)
+	}()
+	select {
+	case <-sent:
+		t.Fatal("should block")
+	case <-time.After(10 * time.Millisecond):
+	}
+	assert.Equal(t, <-out, 0)
+	<-sent
+	for i := 1; i < 6; i++ {
+		assert.Equal(t, <-out, i)
+	}
+	assert.Equal(t, c.DropCount(), 0)
+	c.Close()
+}
+
+func TestWithSoftLimitOverload(t *testing.T) {
+	c, clock := newTestSoftLimitChannel(t)
+	in := c.In()
+	out := c.Out()
+	clock.Advance(time.Second)
+	in <- 5
+	in <- 6
+	waitFor(t, func() bool {
+		return c.DropCount() == 2
+	})
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, <-out, i)
+	}
+	in <- 7
+	assert.Equal(t, <-out, 7)
+	assert.Equal(t, c.DropCount(), 2)
+	c.Close()
+}
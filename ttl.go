@@ -0,0 +1,151 @@
+package unlimitedchannel
+
+import (
+	"time"
+)
+
+// WithValueTTL discards the values that are not sent to the output channel within a duration after they were received.
+//
+// The worker waits for the deadline of the first value with a timer, so an expired value is discarded even if nothing else happens in the channel.
+// The values already buffered in the output channel are never discarded.
+// The discarded values are counted by DropCount, and passed to the function of WithOnDrop.
+// The time is provided by the Clock set by WithClock.
+// It uses a FIFO queue, so it takes precedence over WithQueueImpl, and it is ignored with WithPriority, WithCoalesceKey, WithConflate and NewLanes.
+// A zero or negative duration disables it.
+func WithValueTTL[T any](d time.Duration) Option[T] {
+	return func(o *options[T]) {
+		o.valueTTL = d
+	}
+}
+
+// expirer is implemented by the queues that can expire their values.
+type expirer[T any] interface {
+	// dequeueExpired removes the first value if it is expired.
+	dequeueExpired(now time.Time) (T, bool)
+	// headDeadline returns the deadline of the first value.
+	headDeadline() (time.Time, bool)
+}
+
+// dropExpired discards the expired values, see WithValueTTL.
+func (c *Channel[T]) dropExpired() {
+	if c.expirer == nil {
+		return
+	}
+	now := c.options.getClock().Now()
+	for {
+		value, ok := c.expirer.dequeueExpired(now)
+		if !ok {
+			return
+		}
+		c.length.Add(-1)
+		c.bytes -= c.sizeOf(value)
+		c.removeHead(false)
+		c.drop(value, errDroppedExpired)
+	}
+}
+
+// ttlC returns the channel of the timer that waits for the deadline of the first value, or a nil channel if it is not needed.
+//
+// It starts, resets or stops the timer, depending on the first value.
+func (c *Channel[T]) ttlC() <-chan time.Time {
+	if c.expirer == nil {
+		return nil
+	}
+	deadline, ok := c.expirer.headDeadline()
+	if !ok {
+		if c.ttlTimerActive {
+			c.ttlTimer.Stop()
+			c.ttlTimerActive = false
+		}
+		return nil
+	}
+	if !c.ttlTimerActive || !deadline.Equal(c.ttlDeadline) {
+		d := deadline.Sub(c.options.getClock().Now())
+		if c.ttlTimer == nil {
+			c.ttlTimer = c.options.getClock().NewTimer(d)
+		} else {
+			c.ttlTimer.Reset(d)
+		}
+		c.ttlTimerActive = true
+		c.ttlDeadline = deadline
+	}
+	return c.ttlTimer.C()
+}
+
+// onTTLTimer is called when the first value expires, it is discarded by prepare.
+func (c *Channel[T]) onTTLTimer() {
+	c.ttlTimerActive = false
+}
+
+type ttlItem[T any] struct {
+	value    T
+	deadline time.Time
+}
+
+// ttlQueue is a FIFO queue that stores the deadline of each value.
+type ttlQueue[T any] struct {
+	items linkedQueue[ttlItem[T]]
+	ttl   time.Duration
+	clock Clock
+}
+
+func newTTLQueue[T any](ttl time.Duration, clock Clock) *ttlQueue[T] {
+	return &ttlQueue[T]{
+		items: linkedQueue[ttlItem[T]]{
+			allocator: new(allocator[ttlItem[T]]),
+		},
+		ttl:   ttl,
+		clock: clock,
+	}
+}
+
+func (q *ttlQueue[T]) dequeueExpired(now time.Time) (T, bool) {
+	item, ok := q.items.pick()
+	if !ok || now.Before(item.deadline) {
+		var zero T
+		return zero, false
+	}
+	q.items.dequeue()
+	return item.value, true
+}
+
+func (q *ttlQueue[T]) headDeadline() (time.Time, bool) {
+	item, ok := q.items.pick()
+	return item.deadline, ok
+}
+
+func (q *ttlQueue[T]) enqueue(value T) {
+	q.items.enqueue(ttlItem[T]{
+		value:    value,
+		deadline: q.clock.Now().Add(q.ttl),
+	})
+}
+
+func (q *ttlQueue[T]) dequeue() (T, bool) {
+	item, ok := q.items.dequeue()
+	return item.value, ok
+}
+
+func (q *ttlQueue[T]) pick() (T, bool) {
+	item, ok := q.items.pick()
+	return item.value, ok
+}
+
+func (q *ttlQueue[T]) filter(keep func(T) bool) int {
+	return q.items.filter(func(item ttlItem[T]) bool {
+		return keep(item.value)
+	})
+}
+
+func (q *ttlQueue[T]) appendTo(s []T) []T {
+	for _, item := range q.items.appendTo(nil) {
+		s = append(s, item.value)
+	}
+	return s
+}
+
+func (q *ttlQueue[T]) flush() {}
+
+func (q *ttlQueue[T]) reset() {
+	q.items.reset()
+}
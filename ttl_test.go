@@ -0,0 +1,73 @@
+package unlimitedchannel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithValueTTL(t *testing.T) {
+	clock := newFakeClock()
+	var dropped []int
+	c := New(
+		WithValueTTL[int](time.Second),
+		WithClock[int](clock),
+		WithOnDrop(func(v int) {
+			dropped = append(dropped, v)
+		}),
+	)
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	fill := cap(c.out)
+	for i := 0; i < fill; i++ {
+		in <- -1
+	}
+	waitQueueLen(t, c, 0)
+	in <- 1
+	in <- 2
+	waitQueueLen(t, c, 2)
+	clock.Advance(time.Second)
+	in <- 3
+	waitFor(t, func() bool {
+		return c.DropCount() == 2
+	})
+	for i := 0; i < fill; i++ {
+		<-out
+	}
+	assert.Equal(t, <-out, 3)
+	assert.SliceEqual(t, dropped, []int{1, 2})
+}
+
+func TestWithValueTTLSlowConsumer(t *testing.T) {
+	clock := newFakeClock()
+	dropped := make(chan int, 1)
+	c := New(
+		WithValueTTL[int](time.Second),
+		WithClock[int](clock),
+		WithOnDrop(func(v int) {
+			dropped <- v
+		}),
+	)
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	fill := cap(c.out)
+	for i := 0; i < fill; i++ {
+		in <- -1
+	}
+	waitQueueLen(t, c, 0)
+	in <- 1
+	waitQueueLen(t, c, 1)
+	clock.Advance(time.Second)
+	assert.Equal(t, <-dropped, 1)
+	for i := 0; i < fill; i++ {
+		assert.Equal(t, <-out, -1)
+	}
+	select {
+	case v := <-out:
+		t.Fatalf("unexpected value %d", v)
+	default:
+	}
+}
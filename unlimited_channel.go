@@ -2,7 +2,14 @@
 package unlimitedchannel
 
 import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/pierrre/go-libs/goroutine"
 )
@@ -10,14 +17,75 @@ import (
 // Channel is an unlimited channel.
 // It can store an unlimited number of values.
 //
+// The zero value is ready to use with the default options.
+// Use New in order to configure it.
+//
 // The channel returned by In() must be closed in order to release resources.
 type Channel[T any] struct {
-	once sync.Once
+	once      sync.Once
+	closeOnce sync.Once
+	closeMu   sync.RWMutex // See WithConcurrentSafeClose.
+	closing   chan struct{}
+	helpers   sync.WaitGroup // Goroutines that use the Channel, in addition to the worker, see Reset.
+	options   options[T]
+
+	queue     queue[T]
+	replacer  replacer[T]
+	expirer   expirer[T]
+	length    atomic.Int64
+	maxLen    atomic.Int64
+	dropped   atomic.Uint64
+	enqueued  atomic.Uint64
+	dequeued  atomic.Uint64
+	removed   atomic.Uint64
+	capacity  int
+	bytes     int64 // Size of the values stored in the queue, see WithQueueLimitBytes.
+	inClosed  bool
+	paused    bool
+	requested int
+
+	pendingEpochs []uint64
+	last          T
+	lastValid     bool
+	releaseDone   <-chan struct{}
+	inStopped     chan struct{} // Closed when the worker stops receiving without the input channel being closed, see stopInput.
+
+	snapshotTicker Ticker
+
+	softLimitTimer       Timer
+	softLimitTimerActive bool
+	shedding             bool
+	rateTimer            Timer
+	rateTimerActive      bool
+	rateTokens           float64
+	rateLast             time.Time
+	ttlTimer             Timer
+	ttlTimerActive       bool
+	ttlDeadline          time.Time
+	aboveHigh            bool
+	flushWaiters         []chan struct{}
+	roomWaiters          []chan struct{}
+	acks                 []ackItem
+	headPos              uint64
+	seqs                 seqTracker
+	outSeqs              seqTracker
+	outSeqsMu            sync.Mutex
+	outReceived          atomic.Uint64
 
-	queue queue[T]
+	in     chan T
+	out    chan T
+	epochs chan int
+	ctrl   chan func()
+	done   chan struct{}
+}
 
-	in  chan T
-	out chan T
+// New creates a new Channel with options.
+func New[T any](opts ...Option[T]) *Channel[T] {
+	c := &Channel[T]{
+		options: newOptions(opts),
+	}
+	c.ensureInit()
+	return c
 }
 
 func (c *Channel[T]) ensureInit() {
@@ -25,36 +93,419 @@ func (c *Channel[T]) ensureInit() {
 }
 
 func (c *Channel[T]) init() {
+	if c.queue == nil {
+		c.queue = newQueue(&c.options)
+	}
+	c.replacer, _ = c.queue.(replacer[T])
+	c.expirer, _ = c.queue.(expirer[T])
+	// The replaced values must be stored in the queue, so the window would hide them (see WithCoalesceKey and WithConflate).
+	if c.options.windowSortSize > 1 && c.replacer == nil {
+		c.queue = newWindowSortQueue(c.queue, c.options.windowSortSize, c.options.windowSortLess)
+	}
+	c.capacity = c.options.maxCapacity
+	c.paused = c.options.startPaused
+	if c.options.strictSequential {
+		c.capacity = 1
+	}
 	// Using buffered channels seems to improve performance.
-	c.in = make(chan T, 10)
-	c.out = make(chan T, 10)
-	goroutine.Go(func() {
-		c.run()
-	})
+	bufferSize := 10
+	if c.capacity > 0 || c.options.limitBytes > 0 || c.options.softLimit > 0 || c.options.conflate {
+		// The values buffered in the channels can't be limited, and the worker is not notified when a value is received from the output buffer.
+		// So the channels are unbuffered, and the capacity (or limits, or conflation) only applies to the queue.
+		bufferSize = 0
+	}
+	c.in = make(chan T, bufferSize)
+	c.out = make(chan T, bufferSize)
+	c.epochs = make(chan int)
+	c.ctrl = make(chan func())
+	c.done = make(chan struct{})
+	c.inStopped = make(chan struct{})
+	if c.options.safeClose {
+		c.closing = make(chan struct{})
+	}
+	c.log(slog.LevelDebug, "unlimited channel created")
+	if c.options.runner != nil {
+		c.start()
+		c.options.runner.add(c)
+	} else {
+		goroutine.Go(func() {
+			c.runWorker()
+		})
+	}
+	if c.options.metricsInterval > 0 {
+		c.goHelper(c.runMetrics)
+	}
+}
+
+// goHelper runs a function in a goroutine that uses the Channel, see Reset.
+func (c *Channel[T]) goHelper(f func()) {
+	goroutine.WaitGroup(&c.helpers, f)
 }
 
 func (c *Channel[T]) run() {
-	defer close(c.out)
-	defer c.queue.reset()
-	for {
-		outValue, okOutValue := c.queue.pick()
-		var inValue T
-		var okInValue bool
-		if okOutValue {
-			select {
-			case inValue, okInValue = <-c.in:
-			case c.out <- outValue:
-				c.queue.dequeue()
-				continue
+	c.start()
+	defer c.finish()
+	for !c.isStopped() {
+		c.prepare()
+		c.step()
+	}
+}
+
+// start initializes the state of the worker.
+func (c *Channel[T]) start() {
+	if c.options.snapshotInterval > 0 {
+		c.snapshotTicker = c.options.getClock().NewTicker(c.options.snapshotInterval)
+	}
+	if c.options.releaseCtx != nil {
+		c.releaseDone = c.options.releaseCtx.Done()
+	}
+}
+
+// finish releases the resources of the worker, and closes the channels.
+func (c *Channel[T]) finish() {
+	if c.snapshotTicker != nil {
+		c.snapshotTicker.Stop()
+	}
+	if c.rateTimerActive {
+		c.rateTimer.Stop()
+	}
+	if c.ttlTimerActive {
+		c.ttlTimer.Stop()
+	}
+	c.reset()
+	c.log(slog.LevelDebug, "unlimited channel stopped")
+	close(c.out)
+	close(c.epochs)
+	if c.options.onClose != nil {
+		c.callback(c.options.onClose)
+	}
+	close(c.done)
+}
+
+// prepare runs the actions that don't wait for an event.
+func (c *Channel[T]) prepare() {
+	c.dropExpired()
+	c.flushWindowOnLimit()
+	c.refillRateTokens()
+	c.checkWatermarks()
+	c.notifyFlushed()
+	c.notifyRoom()
+}
+
+// step waits for the next event, and handles it.
+func (c *Channel[T]) step() {
+	// A nil channel is never selected.
+	in := c.inC()
+	out, outValue := c.nextOut()
+	epochs, epoch := c.nextEpoch()
+	softLimitC := c.softLimitC()
+	rateLimitC := c.rateLimitC()
+	ttlC := c.ttlC()
+	select {
+	case inValue, ok := <-in:
+		c.onReceive(inValue, ok)
+	case out <- outValue:
+		c.dequeue()
+	case epochs <- epoch:
+		c.epochSent()
+	case f := <-c.ctrl:
+		f()
+	case <-c.snapshotC():
+		c.snapshot()
+	case <-c.releaseDone:
+		c.releaseDone = nil
+		c.onReleased()
+	case <-softLimitC:
+		c.onSoftLimitTimeout()
+	case <-rateLimitC:
+		c.onRateLimitTimer()
+	case <-ttlC:
+		c.onTTLTimer()
+	}
+}
+
+// inC returns the input channel if a value can be received, or a nil channel otherwise.
+func (c *Channel[T]) inC() chan T {
+	if !c.canReceive() {
+		return nil
+	}
+	return c.in
+}
+
+// nextOut returns the output channel and the next value if it can be sent, or a nil channel otherwise.
+func (c *Channel[T]) nextOut() (chan T, T) {
+	value, ok := c.queue.pick()
+	if !ok || !c.canSend() {
+		return nil, value
+	}
+	c.markNextOut()
+	return c.out, value
+}
+
+// isStopped returns true if the worker must stop.
+func (c *Channel[T]) isStopped() bool {
+	return c.inClosed && (c.options.closeMode == CloseImmediate || c.queueLen() == 0)
+}
+
+func (c *Channel[T]) onReceive(value T, ok bool) {
+	if !ok {
+		c.onInputClosed()
+		return
+	}
+	c.receive(value)
+	c.receiveBuffered()
+}
+
+// onInputClosed stops receiving values.
+// The remaining values are sent unless the CloseMode is CloseImmediate, otherwise the worker stops.
+func (c *Channel[T]) onInputClosed() {
+	c.inClosed = true
+	c.queue.flush()
+	c.log(slog.LevelDebug, "unlimited channel input closed")
+}
+
+// onReleased is called when the channel is released by WithReleaseOnContextCancel.
+func (c *Channel[T]) onReleased() {
+	c.log(slog.LevelDebug, "unlimited channel released")
+	if c.options.closeMode == CloseSendAll && !c.inClosed {
+		c.receiveBuffered()
+	}
+	c.stopReceiving()
+}
+
+// stopInput makes the worker stop receiving from the input channel, as if it was closed, but without closing it.
+//
+// Unlike Close, it is safe if the input channel is closed by someone else, e.g. the goroutine of Map or Wrap.
+// Then the sends on the input channel block, and the methods don't send (see isInStopped).
+func (c *Channel[T]) stopInput() {
+	c.do(func() {
+		// The values already sent are received, as if the input channel was closed.
+		if !c.inClosed {
+			c.receiveBuffered()
+		}
+		c.stopReceiving()
+	})
+}
+
+// stopReceiving stops receiving from the input channel without closing it, see stopInput.
+func (c *Channel[T]) stopReceiving() {
+	if c.inClosed {
+		return
+	}
+	c.onInputClosed()
+	close(c.inStopped)
+}
+
+// isInStopped returns true if the worker stopped receiving without the input channel being closed, see stopInput.
+func (c *Channel[T]) isInStopped() bool {
+	select {
+	case <-c.inStopped:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *Channel[T]) canReceive() bool {
+	if c.inClosed || (c.isFull() && !c.options.dropOldest && !c.options.dropNewest) {
+		return false
+	}
+	return !c.softLimitReached() || c.shedding
+}
+
+func (c *Channel[T]) isFull() bool {
+	return (c.capacity > 0 && c.queueLen() >= c.capacity) || c.limitBytesReached()
+}
+
+// isFullFor returns true if storing the value would exceed the capacity or the limit of WithQueueLimitBytes.
+func (c *Channel[T]) isFullFor(value T) bool {
+	return (c.capacity > 0 && c.queueLen() >= c.capacity) || c.limitBytesExceeded(value)
+}
+
+// receive adds a value received from the input channel to the queue, and returns false if it is discarded.
+// It discards the duplicate values (see WithDedup), replaces the values with the same key (see WithCoalesceKey), discards the values over the soft limit (see WithSoftLimit), and applies the eviction policy if the capacity is reached.
+func (c *Channel[T]) receive(value T) bool {
+	c.enqueued.Add(1)
+	if c.isDuplicate(value) {
+		c.drop(value, errDroppedDuplicate)
+		return false
+	}
+	if c.replace(value) {
+		return true
+	}
+	if c.shedding && c.softLimitReached() {
+		c.drop(value, errDroppedSoftLimit)
+		return false
+	}
+	if !c.evict(value) {
+		return false
+	}
+	c.enqueue(value)
+	return true
+}
+
+// evict applies the eviction policy if there is no room for the value, and returns false if the value is discarded.
+func (c *Channel[T]) evict(value T) bool {
+	switch {
+	case c.options.dropNewest:
+		if c.isFullFor(value) {
+			c.drop(value, errDroppedCapacity)
+			return false
+		}
+	case c.options.dropOldest:
+		if c.isFullFor(value) {
+			// The values of a partial sort window can't be dequeued, see WithWindowSort.
+			c.queue.flush()
+		}
+		for c.queueLen() > 0 && c.isFullFor(value) {
+			oldValue, ok := c.queue.dequeue()
+			if !ok {
+				break
 			}
-		} else {
-			inValue, okInValue = <-c.in
+			c.length.Add(-1)
+			c.bytes -= c.sizeOf(oldValue)
+			c.removeHead(false)
+			c.drop(oldValue, errDroppedCapacity)
 		}
-		if !okInValue {
-			return
+	}
+	return true
+}
+
+func (c *Channel[T]) canSend() bool {
+	return !c.paused && c.rateAllowed() && (!c.options.requestMode || c.requested > 0)
+}
+
+// drop is called for each discarded value, see WithOnDrop, WithErrorChannel and WithLogger.
+func (c *Channel[T]) drop(value T, reason error) {
+	c.dropped.Add(1)
+	c.reportError(reason)
+	c.log(slog.LevelWarn, "unlimited channel value dropped", slog.Any("error", reason))
+	if c.options.onDrop != nil {
+		c.callback(func() {
+			c.options.onDrop(value)
+		})
+	}
+}
+
+// snapshotC returns the channel of the ticker of WithSnapshotInterval, or a nil channel if it is disabled.
+func (c *Channel[T]) snapshotC() <-chan time.Time {
+	if c.snapshotTicker == nil {
+		return nil
+	}
+	return c.snapshotTicker.C()
+}
+
+func (c *Channel[T]) snapshot() {
+	values := c.queue.appendTo(make([]T, 0, c.queueLen()))
+	c.callback(func() {
+		c.options.snapshotFunc(values)
+	})
+}
+
+func (c *Channel[T]) enqueue(value T) {
+	c.markEnqueued()
+	c.queue.enqueue(value)
+	c.setLast(value)
+	c.length.Add(1)
+	c.bytes += c.sizeOf(value)
+	// The worker is the only writer, so it doesn't need a compare-and-swap.
+	if l := int64(c.Len()); l > c.maxLen.Load() {
+		c.maxLen.Store(l)
+	}
+}
+
+func (c *Channel[T]) dequeue() {
+	value, _ := c.queue.dequeue()
+	c.length.Add(-1)
+	c.bytes -= c.sizeOf(value)
+	c.dequeued.Add(1)
+	c.removeHead(true)
+	c.consumeRateToken()
+	if c.options.requestMode {
+		c.requested--
+	}
+}
+
+// receiveBuffered enqueues the values that are already buffered in the input channel.
+//
+// It never blocks, because the worker is the only receiver.
+// It reduces the number of select iterations when many producers are sending concurrently.
+func (c *Channel[T]) receiveBuffered() {
+	for n := len(c.in); n > 0; n-- {
+		c.receive(<-c.in)
+	}
+}
+
+// do calls f in the worker goroutine, and waits until it returns.
+// It returns false if the worker is stopped.
+func (c *Channel[T]) do(f func()) bool {
+	c.ensureInit()
+	called := make(chan struct{})
+	select {
+	case c.ctrl <- func() {
+		defer close(called)
+		f()
+	}:
+	case <-c.done:
+		return false
+	}
+	<-called
+	return true
+}
+
+// filterQueue removes the values stored in the queue for which keep returns false, and returns the number of removed values.
+func (c *Channel[T]) filterQueue(keep func(T) bool) int {
+	af := &ackFilter{
+		acks: c.acks,
+		pos:  c.headPos,
+	}
+	var sf *seqFilter
+	if c.options.seqNumbers {
+		sf = &seqFilter{
+			seqs: &c.seqs,
+			pos:  c.headPos,
 		}
-		c.queue.enqueue(inValue)
 	}
+	ef := &epochFilter{
+		epochs: c.pendingEpochs,
+		pos:    c.dequeued.Load(),
+	}
+	removed := c.queue.filter(func(value T) bool {
+		k := keep(value)
+		af.next(k)
+		if sf != nil {
+			sf.next(k)
+		}
+		ef.next(k)
+		if !k {
+			c.bytes -= c.sizeOf(value)
+		}
+		return k
+	})
+	ef.finish()
+	c.acks = af.kept
+	if sf != nil {
+		c.seqs = sf.kept
+	}
+	for _, nack := range af.nacks {
+		c.callAck(nack)
+	}
+	if removed > 0 {
+		// The last value may have been removed.
+		c.lastValid = false
+	}
+	c.length.Add(int64(-removed))
+	c.removed.Add(uint64(removed))
+	return removed
+}
+
+func (c *Channel[T]) reset() {
+	c.removed.Add(uint64(c.queueLen()))
+	c.queue.reset()
+	c.length.Store(0)
+	c.bytes = 0
+	c.seqs.reset()
+	c.nackAll()
 }
 
 // In returns the input channel.
@@ -68,7 +519,559 @@ func (c *Channel[T]) In() chan<- T {
 // Out returns the output channel.
 //
 // It is automatically closed when the input channel is closed.
+// By default, the values remaining in the queue are discarded, see WithCloseMode.
 func (c *Channel[T]) Out() <-chan T {
 	c.ensureInit()
 	return c.out
 }
+
+// Done returns a channel that is closed when the worker has stopped.
+//
+// At this point, the output channel is closed and the resources are released, but the values buffered in the output channel can still be received.
+func (c *Channel[T]) Done() <-chan struct{} {
+	c.ensureInit()
+	return c.done
+}
+
+// Closed returns true if the worker has stopped, see Done.
+func (c *Channel[T]) Closed() bool {
+	select {
+	case <-c.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// Reset reinitializes a closed Channel with new options, so it can be used again.
+//
+// The worker must have stopped (see Done), otherwise it panics.
+// The queue is reused, so the options that create it (WithQueueImpl, WithPriority, WithCoalesceKey, WithConflate, WithValueTTL, WithInitialCapacity and WithAllocator) are ignored.
+// The statistics are reset.
+// It waits until the goroutines started by the Channel (see WithMetrics, CloseAfter and FromSlice) have returned.
+// It must not be called concurrently with other methods.
+func (c *Channel[T]) Reset(opts ...Option[T]) {
+	if !c.Closed() {
+		panic(c.describe() + ": reset of an active channel")
+	}
+	c.helpers.Wait()
+	q := c.queue
+	if wq, ok := q.(*windowSortQueue[T]); ok {
+		q = wq.queue
+	}
+	*c = Channel[T]{
+		options: newOptions(opts),
+		queue:   q,
+	}
+	c.ensureInit()
+}
+
+// Close closes the input channel.
+//
+// It can be called multiple times, but it panics if the input channel was closed directly.
+func (c *Channel[T]) Close() {
+	c.ensureInit()
+	c.closeOnce.Do(c.closeIn)
+}
+
+// CloseAfter makes the worker stop receiving from the input channel after a duration, as if it was closed.
+//
+// It allows to collect the values during a time window.
+// The input channel is not closed, so it does nothing if the input channel is closed before, and it is still safe to close it after.
+// The values sent after are never delivered, like with ForEach.
+// The duration uses the Clock set by WithClock.
+func (c *Channel[T]) CloseAfter(d time.Duration) {
+	c.ensureInit()
+	t := c.options.getClock().NewTimer(d)
+	c.goHelper(func() {
+		select {
+		case <-t.C():
+			c.stopInput()
+		case <-c.done:
+			t.Stop()
+		}
+	})
+}
+
+// All returns an iterator over the values received from the output channel, until it is closed.
+//
+// Breaking the loop stops receiving: the values that were not yielded stay in the channel, and can be received later.
+func (c *Channel[T]) All() iter.Seq[T] {
+	out := c.Out()
+	return func(yield func(T) bool) {
+		for v := range out {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of values held by the channel.
+//
+// It includes the values stored in the queue, and the values buffered in the input and output channels.
+// It is safe to call it concurrently, and the result is approximate while values are flowing.
+func (c *Channel[T]) Len() int {
+	c.ensureInit()
+	return c.queueLen() + len(c.in) + len(c.out)
+}
+
+// MemoryEstimate returns an approximate size in bytes of the values held by the channel.
+//
+// The values buffered in the input and output channels are counted with the flat size of T.
+// The default queue stores the values in chunks of 64 values, which are counted entirely, because their unused slots are allocated.
+// The head chunk can be partially consumed, so a chunk can be missed.
+// For the other queue implementations (see WithQueueImpl, WithPriority, WithCoalesceKey, WithConflate, WithValueTTL and NewLanes), only the flat size of the stored values is counted.
+// The indirect data of the values (e.g. the content of slices, strings, maps or pointers) is not measured.
+// It is safe to call it concurrently.
+func (c *Channel[T]) MemoryEstimate() int64 {
+	c.ensureInit()
+	size := int64(reflect.TypeFor[T]().Size())
+	buffered := int64(len(c.in)+len(c.out)) * size
+	l := int64(c.queueLen())
+	if !c.isChunked() {
+		return buffered + l*size
+	}
+	chunks := (l + elementSize - 1) / elementSize
+	return buffered + chunks*int64(reflect.TypeFor[Element[T]]().Size())
+}
+
+// isChunked returns true if the values are stored in the chunks of the default queue, see MemoryEstimate.
+func (c *Channel[T]) isChunked() bool {
+	q := c.queue
+	if wq, ok := q.(*windowSortQueue[T]); ok {
+		q = wq.queue
+	}
+	_, ok := q.(*linkedQueue[T])
+	return ok
+}
+
+// MaxLen returns the maximum value of Len() observed by the worker during the lifetime of the channel.
+//
+// It helps to detect if values are accumulating.
+// It is safe to call it concurrently.
+func (c *Channel[T]) MaxLen() int {
+	return int(c.maxLen.Load())
+}
+
+// DropCount returns the number of values discarded by the eviction policies and the other options (see WithOnDrop) during the lifetime of the channel.
+//
+// It is safe to call it concurrently.
+func (c *Channel[T]) DropCount() uint64 {
+	return c.dropped.Load()
+}
+
+// Name returns the name set by WithName.
+func (c *Channel[T]) Name() string {
+	return c.options.name
+}
+
+// describe returns a description of the channel for the error messages.
+func (c *Channel[T]) describe() string {
+	if c.options.name == "" {
+		return "unlimitedchannel"
+	}
+	return fmt.Sprintf("unlimitedchannel %q", c.options.name)
+}
+
+// Stats contains the statistics of a Channel.
+//
+// The counters are maintained during the lifetime of the channel.
+// If no value is flowing, TotalEnqueued == TotalDequeued + CurrentLen + Dropped + Removed.
+type Stats struct {
+	// Name is the value of Name().
+	Name string
+	// CurrentLen is the number of values stored in the queue.
+	// Unlike Len(), it doesn't include the values buffered in the input and output channels.
+	CurrentLen int
+	// MaxLen is the value of MaxLen().
+	MaxLen int
+	// TotalEnqueued is the number of values received by the worker.
+	TotalEnqueued uint64
+	// TotalDequeued is the number of values sent to the output channel.
+	TotalDequeued uint64
+	// Dropped is the number of discarded values, see DropCount().
+	Dropped uint64
+	// Removed is the number of values removed from the queue by Filter() or Drain(), or discarded when the input channel is closed.
+	Removed uint64
+}
+
+// Stats returns a snapshot of the statistics.
+//
+// The fields are read independently while the worker is running, so it is a best-effort point-in-time view.
+// It is safe to call it concurrently.
+func (c *Channel[T]) Stats() Stats {
+	return Stats{
+		Name:          c.options.name,
+		CurrentLen:    c.queueLen(),
+		MaxLen:        c.MaxLen(),
+		TotalEnqueued: c.enqueued.Load(),
+		TotalDequeued: c.dequeued.Load(),
+		Dropped:       c.dropped.Load(),
+		Removed:       c.removed.Load(),
+	}
+}
+
+// queueLen returns the number of values stored in the queue.
+func (c *Channel[T]) queueLen() int {
+	return int(c.length.Load())
+}
+
+// Pair creates an unlimited channel and returns only its input and output channels.
+//
+// The release function closes the input channel, and must be called in order to release resources.
+// It can be called multiple times.
+func Pair[T any](opts ...Option[T]) (in chan<- T, out <-chan T, release func()) {
+	c := New(opts...)
+	return c.In(), c.Out(), c.Close
+}
+
+// Filter removes the values stored in the queue for which keep returns false, and returns the number of removed values.
+//
+// It doesn't affect the values buffered in the input and output channels.
+// The keep function is called in the worker goroutine.
+func (c *Channel[T]) Filter(keep func(T) bool) int {
+	removed := 0
+	c.do(func() {
+		removed = c.filterQueue(keep)
+	})
+	return removed
+}
+
+// Peek returns the next value of the queue without removing it, or false if the queue is empty.
+//
+// The values already buffered in the output channel are delivered before it.
+func (c *Channel[T]) Peek() (T, bool) {
+	var v T
+	var ok bool
+	c.do(func() {
+		c.receiveBuffered()
+		v, ok = c.queue.pick()
+	})
+	return v, ok
+}
+
+// Snapshot returns a copy of the values stored in the queue, in delivery order, without removing them.
+//
+// It is a point-in-time copy, and its cost is O(n).
+// The values already buffered in the output channel are not included, and they are delivered before.
+// It returns nil if the worker is stopped.
+func (c *Channel[T]) Snapshot() []T {
+	var vs []T
+	c.do(func() {
+		c.receiveBuffered()
+		vs = c.queue.appendTo(make([]T, 0, c.queueLen()))
+	})
+	return vs
+}
+
+// Drain discards all the values held by the channel, and returns the number of discarded values.
+//
+// It includes the values stored in the queue, and the values buffered in the input and output channels.
+// The channel stays open, and the values sent after the call are delivered normally.
+func (c *Channel[T]) Drain() int {
+	drained := 0
+	c.do(func() {
+		c.receiveBuffered()
+		drained = c.filterQueue(func(T) bool {
+			return false
+		})
+		for {
+			select {
+			case <-c.out:
+				c.markReceivedOut(1)
+				drained++
+			default:
+				return
+			}
+		}
+	})
+	return drained
+}
+
+// CloseAndCollectRemaining makes the worker stop receiving from the input channel, as if it was closed, and returns the values that were not received from the output channel yet, in delivery order.
+//
+// It includes the values stored in the queue, and the values buffered in the input and output channels.
+// They are removed from the channel, so they are not delivered by the output channel, which is closed.
+// The input channel is not closed, so it is safe if it was closed before, like with ForEach.
+// The producers must have stopped sending.
+// It returns nil if the worker is stopped.
+func (c *Channel[T]) CloseAndCollectRemaining() []T {
+	var vs []T
+	c.do(func() {
+		c.receiveBuffered()
+		vs = c.appendOutBuffered(vs)
+		vs = c.queue.appendTo(vs)
+		c.filterQueue(func(T) bool {
+			return false
+		})
+		c.stopReceiving()
+	})
+	return vs
+}
+
+// appendOutBuffered removes the values buffered in the output channel, and appends them to a slice.
+//
+// It must be called in the worker goroutine, which is the only sender.
+func (c *Channel[T]) appendOutBuffered(s []T) []T {
+	for {
+		select {
+		case v := <-c.out:
+			c.markReceivedOut(1)
+			s = append(s, v)
+		default:
+			return s
+		}
+	}
+}
+
+// Wrap creates a Channel that receives the values from a source channel.
+//
+// The values are forwarded to the input channel by a goroutine, which closes the input channel when the source channel is closed.
+// The caller must not close the input channel.
+// The Channel sends all its values before closing (see WithSendAllOnClose), unless it is overridden by the options.
+func Wrap[T any](src <-chan T, opts ...Option[T]) *Channel[T] {
+	c := newPipelineChannel(opts)
+	in := c.In()
+	goroutine.Go(func() {
+		defer close(in)
+		for v := range src {
+			in <- v
+		}
+	})
+	return c
+}
+
+// FromSlice creates a Channel that delivers the values of a slice, and then closes.
+//
+// The values are sent by a goroutine, which closes the input channel after.
+// The caller must not close the input channel.
+// The Channel sends all its values before closing (see WithSendAllOnClose), unless it is overridden by the options.
+func FromSlice[T any](vs []T, opts ...Option[T]) *Channel[T] {
+	c := newPipelineChannel(opts)
+	c.goHelper(func() {
+		defer c.Close()
+		c.SendBatch(vs)
+	})
+	return c
+}
+
+// Send sends a value to the input channel, or returns the context error if it is canceled before.
+//
+// It is useful with a capacity set by WithMaxCapacity, where a send can block.
+// If a timeout is set by WithSendTimeout and it expires before, the value is discarded and it returns ErrSendTimeout.
+// Like a send on the input channel, it panics if the input channel is closed, unless WithConcurrentSafeClose is enabled, and it returns ErrClosed.
+// It also returns ErrClosed if the worker stopped receiving without the input channel being closed (see WithReleaseOnContextCancel, ForEach, CloseAfter and CloseAndCollectRemaining).
+func (c *Channel[T]) Send(ctx context.Context, v T) error {
+	c.ensureInit()
+	if !c.lockSend() {
+		return ErrClosed
+	}
+	defer c.unlockSend()
+	if c.isInStopped() {
+		// The input channel can have room, but the value would never be received.
+		return ErrClosed
+	}
+	var timeoutC <-chan time.Time
+	if c.options.sendTimeout > 0 {
+		timer := c.options.getClock().NewTimer(c.options.sendTimeout)
+		defer timer.Stop()
+		timeoutC = timer.C()
+	}
+	select {
+	case c.in <- v:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // We don't need to wrap the context error.
+	case <-timeoutC:
+		c.do(func() {
+			c.enqueued.Add(1)
+			c.drop(v, errDroppedSendTimeout)
+		})
+		return ErrSendTimeout
+	case <-c.closing:
+		return ErrClosed
+	case <-c.inStopped:
+		return ErrClosed
+	}
+}
+
+// Receive receives a value from the output channel, or returns the context error if it is canceled before.
+//
+// It returns false if the output channel is closed.
+func (c *Channel[T]) Receive(ctx context.Context) (T, bool, error) {
+	c.ensureInit()
+	select {
+	case v, ok := <-c.out:
+		return v, ok, nil
+	case <-ctx.Done():
+		var zero T
+		return zero, false, ctx.Err() //nolint:wrapcheck // We don't need to wrap the context error.
+	}
+}
+
+// Collect receives the values from the output channel until it is closed, and returns them.
+//
+// If the context is canceled before, it returns the values received so far and the context error.
+func (c *Channel[T]) Collect(ctx context.Context) ([]T, error) {
+	vs := make([]T, 0, c.Len())
+	for {
+		v, ok, err := c.Receive(ctx)
+		if err != nil {
+			return vs, err
+		}
+		if !ok {
+			return vs, nil
+		}
+		vs = append(vs, v)
+	}
+}
+
+// ForEach calls a function for each value of the output channel, until it is closed.
+//
+// It stops early and returns the first error returned by the function, or the context error if it is canceled.
+// On early stop, the worker stops receiving from the input channel, as if it was closed, in order to release resources.
+// The input channel is not closed, so it is safe if a producer closes it, e.g. with a Channel created by Map or Wrap.
+// The values sent after are never delivered: a send on the input channel blocks, Send returns ErrClosed, SendBatch discards the values, and SendWithAck calls nack.
+func (c *Channel[T]) ForEach(ctx context.Context, f func(T) error) error {
+	for {
+		v, ok, err := c.Receive(ctx)
+		if err == nil && ok {
+			err = f(v)
+		}
+		if err != nil {
+			c.stopInput()
+			return err
+		}
+		if !ok {
+			return nil
+		}
+	}
+}
+
+// TrySend tries to send a value to the channel without blocking, and returns true if it was accepted.
+//
+// It returns false if the capacity set by WithMaxCapacity or the limit set by WithQueueLimitBytes is reached (even with an eviction policy), or if the channel is closed.
+// The value is ordered after the values previously sent to the input channel by the same goroutine.
+func (c *Channel[T]) TrySend(v T) bool {
+	accepted := false
+	c.do(func() {
+		c.receiveBuffered()
+		if c.inClosed || c.isFullFor(v) {
+			return
+		}
+		c.receive(v)
+		accepted = true
+	})
+	return accepted
+}
+
+// SendBatch sends all the values to the channel, in order.
+//
+// The values are handed to the worker at once, instead of being sent one by one to the input channel.
+// With a capacity set by WithMaxCapacity or WithQueueLimitBytes and no eviction policy, they are sent one by one to the input channel, so it blocks until there is room.
+// The values are ordered after the values previously sent to the input channel by the same goroutine.
+// Like a send on the input channel, it panics if the channel is closed, unless WithConcurrentSafeClose is enabled, and the values are discarded.
+// The values are also discarded if the worker stopped receiving without the input channel being closed (see WithReleaseOnContextCancel, ForEach, CloseAfter and CloseAndCollectRemaining).
+func (c *Channel[T]) SendBatch(vs []T) {
+	c.ensureInit()
+	if (c.capacity > 0 || c.options.limitBytes > 0) && !c.options.dropOldest && !c.options.dropNewest {
+		for _, v := range vs {
+			if !c.sendIn(v) {
+				return
+			}
+		}
+		return
+	}
+	closed := true
+	c.do(func() {
+		c.receiveBuffered()
+		if c.inClosed {
+			return
+		}
+		for _, v := range vs {
+			c.receive(v)
+		}
+		closed = false
+	})
+	if closed && !c.options.safeClose && !c.isInStopped() {
+		panic(c.describe() + ": send batch on closed channel")
+	}
+}
+
+// FlushAndWait blocks until the queue and the output channel are empty, or returns the context error if it is canceled before.
+//
+// When it returns, the values previously sent to the input channel by the same goroutine have been received from the output channel.
+// The output channel is checked periodically with the Clock set by WithClock, because receiving a value from it doesn't notify the worker.
+// It also returns if the worker stops.
+// While it waits, the values of a partial window of WithWindowSort are sorted and made available, so it doesn't wait until the window is full.
+func (c *Channel[T]) FlushAndWait(ctx context.Context) error {
+	flushed := make(chan struct{})
+	ok := c.do(func() {
+		c.receiveBuffered()
+		c.flushWaiters = append(c.flushWaiters, flushed)
+		c.notifyFlushed()
+	})
+	if !ok {
+		return nil
+	}
+	select {
+	case <-flushed:
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // We don't need to wrap the context error.
+	}
+	return c.waitOutEmpty(ctx)
+}
+
+// flushPollInterval is the interval used by FlushAndWait to check the output channel.
+const flushPollInterval = time.Millisecond
+
+// waitOutEmpty blocks until the values buffered in the output channel have been received, see FlushAndWait.
+func (c *Channel[T]) waitOutEmpty(ctx context.Context) error {
+	if len(c.out) == 0 {
+		return nil
+	}
+	ticker := c.options.getClock().NewTicker(flushPollInterval)
+	defer ticker.Stop()
+	for len(c.out) > 0 {
+		select {
+		case <-ticker.C():
+		case <-c.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err() //nolint:wrapcheck // We don't need to wrap the context error.
+		}
+	}
+	return nil
+}
+
+// notifyFlushed notifies the FlushAndWait callers if the queue is empty.
+func (c *Channel[T]) notifyFlushed() {
+	if len(c.flushWaiters) == 0 {
+		return
+	}
+	// The values of a partial window can't be delivered until it is full.
+	c.queue.flush()
+	if c.queueLen() > 0 {
+		return
+	}
+	for _, flushed := range c.flushWaiters {
+		close(flushed)
+	}
+	c.flushWaiters = nil
+}
+
+// Request allows the worker to send n more values to the output channel.
+//
+// It only has an effect if the request mode is enabled with WithRequestMode.
+// Requests are cumulative.
+func (c *Channel[T]) Request(n int) {
+	if n <= 0 {
+		return
+	}
+	c.do(func() {
+		c.requested += n
+	})
+}
@@ -1,14 +1,20 @@
 package unlimitedchannel
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/pierrre/assert"
 	"github.com/pierrre/assert/ext/pierrrecompare"
 	"github.com/pierrre/assert/ext/pierrreerrors"
 	"github.com/pierrre/assert/ext/pierrrepretty"
+	"github.com/pierrre/go-libs/goroutine"
 )
 
 func init() {
@@ -56,6 +62,1156 @@ func Test(t *testing.T) {
 	assert.Equal(t, ok, false)
 }
 
+func TestClose(t *testing.T) {
+	c := New[int]()
+	out := c.Out()
+	c.Close()
+	c.Close()
+	_, ok := <-out
+	assert.Equal(t, ok, false)
+}
+
+func TestWithOnClose(t *testing.T) {
+	called := make(chan struct{})
+	c := New(
+		WithSendAllOnClose[int](true),
+		WithOnClose[int](func() {
+			close(called)
+		}),
+	)
+	in := c.In()
+	out := c.Out()
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+	fill := cap(c.out)
+	for i := 0; i < 100-fill; i++ {
+		assert.Equal(t, <-out, i)
+	}
+	waitQueueLen(t, c, 0)
+	select {
+	case <-called:
+		t.Fatal("should not be called")
+	default:
+	}
+	c.Close()
+	<-called
+	for i := 100 - fill; i < 100; i++ {
+		assert.Equal(t, <-out, i)
+	}
+	_, ok := <-out
+	assert.False(t, ok)
+	<-c.Done()
+}
+
+func TestCloseAfter(t *testing.T) {
+	clock := newFakeClock()
+	c := New(WithClock[int](clock), WithSendAllOnClose[int](true))
+	in := c.In()
+	c.CloseAfter(time.Second)
+	for i := 0; i < 10; i++ {
+		in <- i
+	}
+	waitQueueLen(t, c, 0)
+	assert.False(t, c.Closed())
+	clock.Advance(time.Second)
+	vs, err := c.Collect(context.Background())
+	assert.NoError(t, err)
+	assert.SliceLen(t, vs, 10)
+	<-c.Done()
+}
+
+func TestCloseAfterClosed(t *testing.T) {
+	clock := newFakeClock()
+	c := New(WithClock[int](clock), WithSendAllOnClose[int](true))
+	in := c.In()
+	for i := 0; i < 50; i++ {
+		in <- i
+	}
+	c.CloseAfter(time.Second)
+	close(in)
+	// The consumer is slow, so the worker is still running when the duration expires.
+	waitFor(t, func() bool {
+		return c.Len() == 50
+	})
+	clock.Advance(time.Second)
+	vs, err := c.Collect(context.Background())
+	assert.NoError(t, err)
+	assert.SliceLen(t, vs, 50)
+	<-c.Done()
+}
+
+func TestCloseAfterClosedNotReceiving(t *testing.T) {
+	clock := newFakeClock()
+	c := New(WithClock[int](clock), WithMaxCapacity[int](1), WithSendAllOnClose[int](true))
+	c.In() <- 1
+	waitQueueLen(t, c, 1)
+	c.CloseAfter(time.Second)
+	c.Close()
+	clock.Advance(time.Second)
+	assert.Equal(t, <-c.Out(), 1)
+	<-c.Done()
+}
+
+func TestDone(t *testing.T) {
+	c := New[int]()
+	in := c.In()
+	out := c.Out()
+	in <- 1
+	assert.False(t, c.Closed())
+	c.Close()
+	for range out {
+	}
+	<-c.Done()
+	assert.True(t, c.Closed())
+}
+
+func TestReset(t *testing.T) {
+	c := New[int]()
+	in := c.In()
+	out := c.Out()
+	in <- 1
+	assert.Equal(t, <-out, 1)
+	c.Close()
+	<-c.Done()
+	c.Reset(WithName[int]("reset"))
+	assert.Equal(t, c.Name(), "reset")
+	assert.Equal(t, c.Stats().TotalEnqueued, 0)
+	in = c.In()
+	out = c.Out()
+	in <- 2
+	assert.Equal(t, <-out, 2)
+	c.Close()
+	_, ok := <-out
+	assert.False(t, ok)
+}
+
+func TestResetHelpers(t *testing.T) {
+	c := FromSlice([]int{1, 2, 3}, WithMetrics[int](time.Millisecond, func(s Stats) {}))
+	vs, err := c.Collect(context.Background())
+	assert.NoError(t, err)
+	assert.SliceEqual(t, vs, []int{1, 2, 3})
+	<-c.Done()
+	c.Reset()
+	c.Close()
+	<-c.Done()
+}
+
+func TestResetActive(t *testing.T) {
+	c := New[int]()
+	defer c.Close()
+	assert.Panics(t, func() {
+		c.Reset()
+	})
+}
+
+func TestAll(t *testing.T) {
+	c := new(Channel[int])
+	in := c.In()
+	for i := 0; i < 10; i++ {
+		in <- i
+	}
+	var values []int
+	for v := range c.All() {
+		values = append(values, v)
+		if v == 9 {
+			close(in)
+		}
+	}
+	assert.SliceEqual(t, values, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+}
+
+func TestAllBreak(t *testing.T) {
+	c := new(Channel[int])
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	for i := 0; i < 10; i++ {
+		in <- i
+	}
+	for v := range c.All() {
+		if v == 4 {
+			break
+		}
+	}
+	v := <-out
+	assert.Equal(t, v, 5)
+}
+
+func TestSendAllOnClose(t *testing.T) {
+	c := New(WithSendAllOnClose[int](true))
+	in := c.In()
+	out := c.Out()
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+	close(in)
+	for i := 0; i < 100; i++ {
+		v := <-out
+		assert.Equal(t, v, i)
+	}
+	_, ok := <-out
+	assert.False(t, ok)
+	assert.Equal(t, c.Len(), 0)
+}
+
+func TestReleaseOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := New(WithReleaseOnContextCancel[int](ctx))
+	in := c.In()
+	out := c.Out()
+	in <- 1
+	v := <-out
+	assert.Equal(t, v, 1)
+	cancel()
+	for range out {
+	}
+	close(in)
+}
+
+func TestReleaseOnContextCancelSend(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := New(WithReleaseOnContextCancel[int](ctx), WithMaxCapacity[int](1))
+	defer c.Close()
+	err := c.Send(context.Background(), 1)
+	assert.NoError(t, err)
+	waitQueueLen(t, c, 1)
+	cancel()
+	err = c.Send(context.Background(), 2)
+	assert.ErrorIs(t, err, ErrClosed)
+	c.SendBatch([]int{3, 4})
+	<-c.Done()
+	c.SendBatch([]int{5, 6})
+	nacked := false
+	c.SendWithAck(7, nil, func() {
+		nacked = true
+	})
+	assert.True(t, nacked)
+}
+
+func TestReleaseOnContextCancelSendAll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := New(WithReleaseOnContextCancel[int](ctx), WithSendAllOnClose[int](true))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+	waitFor(t, func() bool {
+		return c.queueLen()+len(c.out) == 100
+	})
+	cancel()
+	i := 0
+	for v := range out {
+		assert.Equal(t, v, i)
+		i++
+	}
+	assert.Equal(t, i, 100)
+}
+
+func TestCloseMode(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		mode    CloseMode
+		sendAll bool
+	}{
+		{
+			name: "Immediate",
+			mode: CloseImmediate,
+		},
+		{
+			name:    "Drain",
+			mode:    CloseDrain,
+			sendAll: true,
+		},
+		{
+			name:    "SendAll",
+			mode:    CloseSendAll,
+			sendAll: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := New(WithCloseMode[int](tc.mode))
+			in := c.In()
+			out := c.Out()
+			for i := 0; i < 100; i++ {
+				in <- i
+			}
+			waitFor(t, func() bool {
+				return len(c.out) == cap(c.out)
+			})
+			close(in)
+			count := 0
+			for range out {
+				count++
+			}
+			if tc.sendAll {
+				assert.Equal(t, count, 100)
+			} else {
+				assert.Less(t, count, 100)
+			}
+		})
+	}
+}
+
+func TestCloseModeSendAllRelease(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := New(WithReleaseOnContextCancel[int](ctx), WithCloseMode[int](CloseSendAll))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	// Block the worker, so the values stay buffered in the input channel.
+	blocked := make(chan struct{})
+	unblock := make(chan struct{})
+	go c.do(func() {
+		close(blocked)
+		<-unblock
+	})
+	<-blocked
+	for i := 0; i < cap(c.in); i++ {
+		in <- i
+	}
+	cancel()
+	close(unblock)
+	count := 0
+	for range out {
+		count++
+	}
+	assert.Equal(t, count, cap(c.in))
+}
+
+func TestLen(t *testing.T) {
+	c := new(Channel[int])
+	in := c.In()
+	out := c.Out()
+	assert.Equal(t, c.Len(), 0)
+	stop := make(chan struct{})
+	wait := goroutine.GoWait(func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			l := c.Len()
+			if l < 0 {
+				t.Errorf("negative length: %d", l)
+				return
+			}
+		}
+	})
+	const count = 10000
+	goroutine.Go(func() {
+		for i := 0; i < count; i++ {
+			in <- i
+		}
+	})
+	for i := 0; i < count; i++ {
+		<-out
+	}
+	close(stop)
+	wait()
+	close(in)
+	for range out {
+	}
+	assert.Equal(t, c.Len(), 0)
+}
+
+func TestLenRemaining(t *testing.T) {
+	c := new(Channel[int])
+	in := c.In()
+	out := c.Out()
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+	waitFor(t, func() bool {
+		return c.Len() == 100
+	})
+	for i := 0; i < 30; i++ {
+		<-out
+	}
+	waitFor(t, func() bool {
+		return c.Len() == 70
+	})
+	close(in)
+	for range out {
+	}
+	assert.Equal(t, c.Len(), 0)
+}
+
+func TestMemoryEstimate(t *testing.T) {
+	type value struct {
+		data [16]int64
+	}
+	c := New(WithStartPaused[value](true))
+	in := c.In()
+	defer close(in)
+	assert.Equal(t, c.MemoryEstimate(), 0)
+	in <- value{}
+	waitQueueLen(t, c, 1)
+	chunk := c.MemoryEstimate()
+	assert.Greater(t, chunk, 64*128)
+	for i := 1; i < 64; i++ {
+		in <- value{}
+	}
+	waitQueueLen(t, c, 64)
+	assert.Equal(t, c.MemoryEstimate(), chunk)
+	in <- value{}
+	waitQueueLen(t, c, 65)
+	assert.Equal(t, c.MemoryEstimate(), 2*chunk)
+}
+
+func TestMemoryEstimateRing(t *testing.T) {
+	c := New(WithQueueImpl[int](QueueImplRing), WithStartPaused[int](true))
+	in := c.In()
+	defer close(in)
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+	waitQueueLen(t, c, 100)
+	assert.Equal(t, c.MemoryEstimate(), 100*strconv.IntSize/8)
+}
+
+func TestMaxLen(t *testing.T) {
+	c := new(Channel[int])
+	in := c.In()
+	out := c.Out()
+	assert.Equal(t, c.MaxLen(), 0)
+	for i := 0; i < 1000; i++ {
+		in <- i
+	}
+	waitFor(t, func() bool {
+		return c.MaxLen() == 1000
+	})
+	for i := 0; i < 1000; i++ {
+		<-out
+	}
+	waitFor(t, func() bool {
+		return c.Len() == 0
+	})
+	assert.Equal(t, c.MaxLen(), 1000)
+	close(in)
+}
+
+func TestMaxCapacity(t *testing.T) {
+	c := New(WithMaxCapacity[int](5))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	var sent atomic.Int64
+	goroutine.Go(func() {
+		for i := 0; i < 10; i++ {
+			in <- i
+			sent.Add(1)
+		}
+	})
+	waitFor(t, func() bool {
+		return sent.Load() == 5
+	})
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, sent.Load(), int64(5))
+	assert.Equal(t, c.Len(), 5)
+	v := <-out
+	assert.Equal(t, v, 0)
+	waitFor(t, func() bool {
+		return sent.Load() == 6
+	})
+	for i := 1; i < 10; i++ {
+		v = <-out
+		assert.Equal(t, v, i)
+	}
+	assert.Equal(t, c.MaxLen(), 5)
+}
+
+func TestDropOldest(t *testing.T) {
+	c := New(WithMaxCapacity[int](5), WithDropOldest[int](true))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	for i := 0; i < 20; i++ {
+		in <- i
+	}
+	for i := 15; i < 20; i++ {
+		v := <-out
+		assert.Equal(t, v, i)
+	}
+	assert.Equal(t, c.MaxLen(), 5)
+	assert.Equal(t, c.DropCount(), uint64(15))
+	waitFor(t, func() bool {
+		return c.Len() == 0
+	})
+}
+
+func TestDropNewest(t *testing.T) {
+	c := New(WithMaxCapacity[int](5), WithDropNewest[int](true))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	for i := 0; i < 10; i++ {
+		in <- i
+	}
+	for i := 0; i < 5; i++ {
+		v := <-out
+		assert.Equal(t, v, i)
+	}
+	assert.Equal(t, c.DropCount(), uint64(5))
+	waitFor(t, func() bool {
+		return c.Len() == 0
+	})
+	in <- 10
+	v := <-out
+	assert.Equal(t, v, 10)
+	assert.Equal(t, c.DropCount(), uint64(5))
+}
+
+func TestFromSlice(t *testing.T) {
+	vs := make([]int, 50)
+	for i := range vs {
+		vs[i] = i
+	}
+	c := FromSlice(vs)
+	i := 0
+	for v := range c.Out() {
+		assert.Equal(t, v, i)
+		i++
+	}
+	assert.Equal(t, i, 50)
+}
+
+func TestCollect(t *testing.T) {
+	vs := []int{1, 2, 3}
+	res, err := FromSlice(vs).Collect(context.Background())
+	assert.NoError(t, err)
+	assert.DeepEqual(t, res, vs)
+}
+
+func TestCollectCanceled(t *testing.T) {
+	c := New[int]()
+	in := c.In()
+	in <- 1
+	ctx, cancel := context.WithCancel(context.Background())
+	res := make(chan []int)
+	errs := make(chan error)
+	go func() {
+		vs, err := c.Collect(ctx)
+		res <- vs
+		errs <- err
+	}()
+	waitFor(t, func() bool {
+		return c.Stats().TotalDequeued == 1 && len(c.out) == 0
+	})
+	cancel()
+	assert.DeepEqual(t, <-res, []int{1})
+	assert.ErrorIs(t, <-errs, context.Canceled)
+	c.Close()
+}
+
+func TestForEach(t *testing.T) {
+	var vs []int
+	err := FromSlice([]int{1, 2, 3}).ForEach(context.Background(), func(v int) error {
+		vs = append(vs, v)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.SliceEqual(t, vs, []int{1, 2, 3})
+}
+
+func TestForEachError(t *testing.T) {
+	c := New[int]()
+	in := c.In()
+	for i := 1; i <= 5; i++ {
+		in <- i
+	}
+	errTest := errors.New("test")
+	var vs []int
+	err := c.ForEach(context.Background(), func(v int) error {
+		vs = append(vs, v)
+		if v == 3 {
+			return errTest
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, errTest)
+	assert.SliceEqual(t, vs, []int{1, 2, 3})
+	<-c.Done()
+}
+
+func TestForEachMap(t *testing.T) {
+	src := New(WithSendAllOnClose[int](true))
+	in := src.In()
+	c := Map(src, func(v int) int {
+		return v
+	})
+	for i := 1; i <= 5; i++ {
+		in <- i
+	}
+	errTest := errors.New("test")
+	err := c.ForEach(context.Background(), func(v int) error {
+		if v == 3 {
+			return errTest
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, errTest)
+	<-c.Done()
+	err = c.Send(context.Background(), 6)
+	assert.ErrorIs(t, err, ErrClosed)
+	// The goroutine of Map closes the input channel when the source is closed.
+	src.Close()
+	for range src.Out() {
+	}
+}
+
+func TestForEachCanceled(t *testing.T) {
+	c := New[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := c.ForEach(ctx, func(v int) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	<-c.Done()
+}
+
+func TestSend(t *testing.T) {
+	c := new(Channel[int])
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	err := c.Send(context.Background(), 1)
+	assert.NoError(t, err)
+	v := <-out
+	assert.Equal(t, v, 1)
+}
+
+func TestSendCanceled(t *testing.T) {
+	c := New(WithMaxCapacity[int](1))
+	in := c.In()
+	defer close(in)
+	err := c.Send(context.Background(), 1)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = c.Send(ctx, 2)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestSendTimeout(t *testing.T) {
+	clock := newFakeClock()
+	c := New(
+		WithMaxCapacity[int](1),
+		WithSendTimeout[int](time.Second),
+		WithClock[int](clock),
+	)
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	err := c.Send(context.Background(), 1)
+	assert.NoError(t, err)
+	errC := make(chan error)
+	go func() {
+		errC <- c.Send(context.Background(), 2)
+	}()
+	waitFor(t, func() bool {
+		return clock.activeTimers() == 1
+	})
+	clock.Advance(time.Second)
+	assert.ErrorIs(t, <-errC, ErrSendTimeout)
+	assert.Equal(t, c.DropCount(), 1)
+	assert.Equal(t, <-out, 1)
+	err = c.Send(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, <-out, 3)
+}
+
+func TestReceive(t *testing.T) {
+	c := new(Channel[int])
+	in := c.In()
+	in <- 1
+	v, ok, err := c.Receive(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+	close(in)
+	v, ok, err = c.Receive(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, v, 0)
+}
+
+func TestReceiveCanceled(t *testing.T) {
+	c := new(Channel[int])
+	defer close(c.In())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	v, ok, err := c.Receive(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, ok)
+	assert.Equal(t, v, 0)
+}
+
+func TestOnDrop(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		policy   Option[int]
+		expected []int
+	}{
+		{
+			name:     "Oldest",
+			policy:   WithDropOldest[int](true),
+			expected: []int{0, 1, 2, 3, 4},
+		},
+		{
+			name:     "Newest",
+			policy:   WithDropNewest[int](true),
+			expected: []int{5, 6, 7, 8, 9},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var dropped []int
+			c := New(WithMaxCapacity[int](5), tc.policy, WithOnDrop(func(v int) {
+				dropped = append(dropped, v)
+			}))
+			in := c.In()
+			out := c.Out()
+			for i := 0; i < 10; i++ {
+				in <- i
+			}
+			close(in)
+			for range out {
+			}
+			assert.SliceEqual(t, dropped, tc.expected)
+		})
+	}
+}
+
+func TestTrySend(t *testing.T) {
+	c := new(Channel[int])
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	for i := 0; i < 100; i++ {
+		if i%2 == 0 {
+			in <- i
+		} else {
+			ok := c.TrySend(i)
+			assert.True(t, ok)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		v := <-out
+		assert.Equal(t, v, i)
+	}
+}
+
+func TestTrySendFull(t *testing.T) {
+	c := New(WithMaxCapacity[int](3), WithDropOldest[int](true))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	for i := 0; i < 3; i++ {
+		ok := c.TrySend(i)
+		assert.True(t, ok)
+	}
+	ok := c.TrySend(3)
+	assert.False(t, ok)
+	v := <-out
+	assert.Equal(t, v, 0)
+	ok = c.TrySend(4)
+	assert.True(t, ok)
+	for _, expected := range []int{1, 2, 4} {
+		v = <-out
+		assert.Equal(t, v, expected)
+	}
+	assert.Equal(t, c.DropCount(), uint64(0))
+}
+
+func TestTrySendClosed(t *testing.T) {
+	c := new(Channel[int])
+	close(c.In())
+	for range c.Out() {
+	}
+	waitFor(t, func() bool {
+		return !c.TrySend(1)
+	})
+}
+
+func TestSendBatch(t *testing.T) {
+	c := New[int]()
+	in := c.In()
+	out := c.Out()
+	in <- 0
+	vs := make([]int, 1000)
+	for i := range vs {
+		vs[i] = i + 1
+	}
+	c.SendBatch(vs)
+	in <- 1001
+	for i := 0; i <= 1001; i++ {
+		v := <-out
+		assert.Equal(t, v, i)
+	}
+	c.Close()
+}
+
+func TestSendBatchMaxCapacity(t *testing.T) {
+	c := New(WithMaxCapacity[int](10))
+	out := c.Out()
+	go c.SendBatch([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19})
+	for i := 0; i < 20; i++ {
+		v := <-out
+		assert.Equal(t, v, i)
+	}
+	c.Close()
+}
+
+func TestSendBatchClosed(t *testing.T) {
+	c := New[int]()
+	c.Close()
+	<-c.Done()
+	assert.Panics(t, func() {
+		c.SendBatch([]int{1})
+	})
+}
+
+func TestFlushAndWaitEmpty(t *testing.T) {
+	c := New[int]()
+	err := c.FlushAndWait(context.Background())
+	assert.NoError(t, err)
+	c.Close()
+}
+
+func TestFlushAndWait(t *testing.T) {
+	c := New[int]()
+	in := c.In()
+	out := c.Out()
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+	go func() {
+		for range out {
+		}
+	}()
+	err := c.FlushAndWait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, c.Stats().TotalDequeued, 100)
+	assert.Equal(t, c.Len(), 0)
+	c.Close()
+}
+
+func TestFlushAndWaitOutputBuffered(t *testing.T) {
+	c := New[int]()
+	in := c.In()
+	in <- 1
+	waitQueueLen(t, c, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := c.FlushAndWait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	c.Close()
+}
+
+func TestFlushAndWaitWindowSort(t *testing.T) {
+	c := New(WithWindowSort(4, func(a, b int) bool {
+		return a < b
+	}))
+	in := c.In()
+	out := c.Out()
+	for _, v := range []int{3, 1, 4, 2, 6, 5} {
+		in <- v
+	}
+	go func() {
+		for range out {
+		}
+	}()
+	err := c.FlushAndWait(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, c.Stats().TotalDequeued, 6)
+	assert.Equal(t, c.Len(), 0)
+	c.Close()
+}
+
+func TestFlushAndWaitCanceled(t *testing.T) {
+	c := New[int]()
+	in := c.In()
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := c.FlushAndWait(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	c.Close()
+}
+
+func TestStats(t *testing.T) {
+	c := New(WithMaxCapacity[int](50), WithDropOldest[int](true))
+	in := c.In()
+	out := c.Out()
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+	for i := 0; i < 20; i++ {
+		<-out
+	}
+	removed := c.Filter(func(v int) bool {
+		return v%2 == 0
+	})
+	assert.Equal(t, removed, 15)
+	waitFor(t, func() bool {
+		stats := c.Stats()
+		return stats.TotalEnqueued == stats.TotalDequeued+uint64(stats.CurrentLen)+stats.Dropped+stats.Removed
+	})
+	assert.Equal(t, c.Stats(), Stats{
+		CurrentLen:    15,
+		MaxLen:        50,
+		TotalEnqueued: 100,
+		TotalDequeued: 20,
+		Dropped:       50,
+		Removed:       15,
+	})
+	close(in)
+	for range out {
+	}
+	stats := c.Stats()
+	assert.Equal(t, stats.CurrentLen, 0)
+	assert.Equal(t, stats.Removed, uint64(30))
+}
+
+func TestPair(t *testing.T) {
+	in, out, release := Pair[int]()
+	in <- 1
+	in <- 2
+	v := <-out
+	assert.Equal(t, v, 1)
+	v = <-out
+	assert.Equal(t, v, 2)
+	release()
+	release()
+	_, ok := <-out
+	assert.Equal(t, ok, false)
+}
+
+func TestFilter(t *testing.T) {
+	c := new(Channel[int])
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	fill := cap(c.out)
+	for i := 0; i < fill; i++ {
+		in <- -1
+	}
+	for i := 0; i < 10; i++ {
+		in <- i
+	}
+	waitQueueLen(t, c, 10)
+	removed := c.Filter(func(v int) bool {
+		return v%2 == 0
+	})
+	assert.Equal(t, removed, 5)
+	assert.Equal(t, c.queueLen(), 5)
+	for i := 0; i < fill; i++ {
+		<-out
+	}
+	for _, expected := range []int{0, 2, 4, 6, 8} {
+		v := <-out
+		assert.Equal(t, v, expected)
+	}
+	in <- 10
+	v := <-out
+	assert.Equal(t, v, 10)
+}
+
+func TestSnapshot(t *testing.T) {
+	c := New[int]()
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	fill := cap(c.out)
+	for i := 0; i < fill; i++ {
+		in <- -1
+	}
+	waitQueueLen(t, c, 0)
+	for i := 0; i < 5; i++ {
+		in <- i
+	}
+	vs := c.Snapshot()
+	assert.SliceEqual(t, vs, []int{0, 1, 2, 3, 4})
+	for i := 0; i < fill; i++ {
+		<-out
+	}
+	for _, expected := range vs {
+		assert.Equal(t, <-out, expected)
+	}
+}
+
+func TestCloseAndCollectRemaining(t *testing.T) {
+	c := New[int]()
+	in := c.In()
+	out := c.Out()
+	for i := 0; i < 20; i++ {
+		in <- i
+	}
+	assert.Equal(t, <-out, 0)
+	vs := c.CloseAndCollectRemaining()
+	assert.SliceLen(t, vs, 19)
+	for i, v := range vs {
+		assert.Equal(t, v, i+1)
+	}
+	_, ok := <-out
+	assert.False(t, ok)
+	assert.SliceLen(t, c.CloseAndCollectRemaining(), 0)
+}
+
+func TestCloseAndCollectRemainingClosed(t *testing.T) {
+	c := New(WithCloseMode[int](CloseDrain))
+	in := c.In()
+	for i := 0; i < 20; i++ {
+		in <- i
+	}
+	close(in)
+	waitFor(t, func() bool {
+		return c.queueLen()+len(c.out) == 20
+	})
+	vs := c.CloseAndCollectRemaining()
+	assert.SliceLen(t, vs, 20)
+	<-c.Done()
+}
+
+func TestDrain(t *testing.T) {
+	c := new(Channel[int])
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	for i := 0; i < 100; i++ {
+		in <- i
+	}
+	drained := c.Drain()
+	assert.Equal(t, drained, 100)
+	assert.Equal(t, c.Len(), 0)
+	in <- 100
+	v := <-out
+	assert.Equal(t, v, 100)
+}
+
+func TestPeek(t *testing.T) {
+	// The output channel is unbuffered with a capacity, so the next value is always in the queue.
+	c := New(WithMaxCapacity[int](100))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	_, ok := c.Peek()
+	assert.False(t, ok)
+	in <- 1
+	v, ok := c.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+	in <- 2
+	in <- 3
+	v, ok = c.Peek()
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+	for _, expected := range []int{1, 2, 3} {
+		v = <-out
+		assert.Equal(t, v, expected)
+	}
+	_, ok = c.Peek()
+	assert.False(t, ok)
+}
+
+func TestFilterClosed(t *testing.T) {
+	c := new(Channel[int])
+	close(c.In())
+	for range c.Out() {
+	}
+	removed := c.Filter(func(v int) bool {
+		return false
+	})
+	assert.Equal(t, removed, 0)
+}
+
+func TestWrap(t *testing.T) {
+	src := make(chan int)
+	c := Wrap(src)
+	out := c.Out()
+	for i := 0; i < 100; i++ {
+		src <- i
+	}
+	for i := 0; i < 100; i++ {
+		v := <-out
+		assert.Equal(t, v, i)
+	}
+	close(src)
+	_, ok := <-out
+	assert.False(t, ok)
+}
+
+func TestRequest(t *testing.T) {
+	c := New(WithRequestMode[int](true))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	for i := 0; i < 10; i++ {
+		in <- i
+	}
+	waitQueueLen(t, c, 10)
+	c.Request(3)
+	for i := 0; i < 3; i++ {
+		v := <-out
+		assert.Equal(t, v, i)
+	}
+	select {
+	case <-out:
+		t.Fatal("should not be here")
+	case <-time.After(10 * time.Millisecond):
+	}
+	assert.Equal(t, c.Len(), 7)
+	c.Request(7)
+	for i := 3; i < 10; i++ {
+		v := <-out
+		assert.Equal(t, v, i)
+	}
+}
+
+func TestStrictSequential(t *testing.T) {
+	c := New(WithStrictSequential[int](true))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	var sent atomic.Int64
+	goroutine.Go(func() {
+		for i := 0; i < 3; i++ {
+			in <- i
+			sent.Add(1)
+		}
+	})
+	for i := 0; i < 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, sent.Load(), int64(i+1))
+		v := <-out
+		assert.Equal(t, v, i)
+	}
+}
+
+func waitQueueLen[T any](tb testing.TB, c *Channel[T], l int) {
+	tb.Helper()
+	waitFor(tb, func() bool {
+		return c.queueLen() == l
+	})
+}
+
+func waitFor(tb testing.TB, f func() bool) {
+	tb.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !f() {
+		if time.Now().After(deadline) {
+			tb.Fatal("timeout")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
 func Benchmark(b *testing.B) {
 	for _, count := range []int{0, 1, 10, 100, 1000} {
 		b.Run(strconv.Itoa(count), func(b *testing.B) {
@@ -74,3 +1230,31 @@ func Benchmark(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkProducers(b *testing.B) {
+	for _, producers := range []int{1, 10, 100} {
+		b.Run(strconv.Itoa(producers), func(b *testing.B) {
+			c := new(Channel[int])
+			in := c.In()
+			out := c.Out()
+			defer close(in)
+			b.ResetTimer()
+			wg := new(sync.WaitGroup)
+			for p := 0; p < producers; p++ {
+				count := b.N / producers
+				if p < b.N%producers {
+					count++
+				}
+				goroutine.WaitGroup(wg, func() {
+					for i := 0; i < count; i++ {
+						in <- 1
+					}
+				})
+			}
+			for i := 0; i < b.N; i++ {
+				<-out
+			}
+			wg.Wait()
+		})
+	}
+}
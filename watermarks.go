@@ -0,0 +1,40 @@
+package unlimitedchannel
+
+// WithWatermarks calls functions when the number of values stored in the queue crosses thresholds.
+//
+// onHigh is called when it reaches high, and onLow is called when it goes below low after that.
+// So each function is called once per crossing, and they alternate.
+// It allows the producers to throttle themselves.
+// The values buffered in the input and output channels are not counted.
+// The functions are called in the worker goroutine, so they must be fast and must not block.
+// A nil function is ignored.
+func WithWatermarks[T any](low, high int, onHigh, onLow func()) Option[T] {
+	return func(o *options[T]) {
+		o.watermarkLow = low
+		o.watermarkHigh = high
+		o.onHigh = onHigh
+		o.onLow = onLow
+	}
+}
+
+// checkWatermarks calls the functions of WithWatermarks if a threshold was crossed.
+func (c *Channel[T]) checkWatermarks() {
+	if c.options.onHigh == nil && c.options.onLow == nil {
+		return
+	}
+	l := c.queueLen()
+	switch {
+	case !c.aboveHigh && l >= c.options.watermarkHigh:
+		c.aboveHigh = true
+		c.callWatermark(c.options.onHigh)
+	case c.aboveHigh && l < c.options.watermarkLow:
+		c.aboveHigh = false
+		c.callWatermark(c.options.onLow)
+	}
+}
+
+func (c *Channel[T]) callWatermark(f func()) {
+	if f != nil {
+		c.callback(f)
+	}
+}
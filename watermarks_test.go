@@ -0,0 +1,47 @@
+package unlimitedchannel
+
+import (
+	"testing"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithWatermarks(t *testing.T) {
+	events := make(chan string, 10)
+	c := New(WithWatermarks[int](2, 5, func() {
+		events <- "high"
+	}, func() {
+		events <- "low"
+	}))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	fill := cap(c.out)
+	for i := 0; i < fill; i++ {
+		in <- i
+	}
+	waitQueueLen(t, c, 0)
+	// The queue may have crossed the thresholds while the output buffer was filled.
+	// Peek waits for the worker to check them.
+	c.Peek()
+	for len(events) > 0 {
+		<-events
+	}
+	for i := 0; i < 4; i++ {
+		in <- i
+	}
+	waitQueueLen(t, c, 4)
+	assert.Equal(t, len(events), 0)
+	in <- 4
+	assert.Equal(t, <-events, "high")
+	in <- 5
+	in <- 6
+	waitQueueLen(t, c, 7)
+	for i := 0; i < fill; i++ {
+		<-out
+	}
+	// The output buffer is refilled from the queue.
+	waitQueueLen(t, c, 0)
+	assert.Equal(t, <-events, "low")
+	assert.Equal(t, len(events), 0)
+}
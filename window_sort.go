@@ -0,0 +1,100 @@
+package unlimitedchannel
+
+import (
+	"sort"
+)
+
+// WithWindowSort sorts the values by windows of a fixed size.
+//
+// The worker collects the received values until the window is full, then sorts it and adds it to the queue.
+// It gives an approximately sorted output, with an additional latency of up to a window.
+// The values of a partial window are not delivered until it is full.
+// When the input channel is closed, it is sorted and sent, unless the CloseMode is CloseImmediate (see WithCloseMode): then it is discarded with the rest of the queue.
+// If a limit is reached (see WithMaxCapacity, WithSoftLimit and WithQueueLimitBytes), the partial window is sorted and made available, because it can't be filled.
+// It is ignored with WithCoalesceKey and WithConflate.
+// A size lower than 2 disables it.
+func WithWindowSort[T any](size int, less func(a, b T) bool) Option[T] {
+	return func(o *options[T]) {
+		o.windowSortSize = size
+		o.windowSortLess = less
+	}
+}
+
+// flushWindowOnLimit sorts and makes available the values of a partial window if a limit is reached, see WithWindowSort.
+//
+// The values of the window are counted by the limits, so the worker stops receiving before the window is full.
+func (c *Channel[T]) flushWindowOnLimit() {
+	if c.isFull() || c.softLimitReached() {
+		c.queue.flush()
+	}
+}
+
+type windowSortQueue[T any] struct {
+	queue[T]
+	window []T
+	size   int
+	less   func(a, b T) bool
+}
+
+func newWindowSortQueue[T any](q queue[T], size int, less func(a, b T) bool) *windowSortQueue[T] {
+	return &windowSortQueue[T]{
+		queue:  q,
+		window: make([]T, 0, size),
+		size:   size,
+		less:   less,
+	}
+}
+
+func (q *windowSortQueue[T]) enqueue(value T) {
+	q.window = append(q.window, value)
+	if len(q.window) < q.size {
+		return
+	}
+	q.flush()
+}
+
+func (q *windowSortQueue[T]) flush() {
+	sort.SliceStable(q.window, func(i, j int) bool {
+		return q.less(q.window[i], q.window[j])
+	})
+	for _, v := range q.window {
+		q.queue.enqueue(v)
+	}
+	q.clearWindow()
+}
+
+func (q *windowSortQueue[T]) filter(keep func(T) bool) int {
+	removed := q.queue.filter(keep)
+	window := q.window[:0]
+	for _, v := range q.window {
+		if keep(v) {
+			window = append(window, v)
+		} else {
+			removed++
+		}
+	}
+	var zero T
+	for i := len(window); i < len(q.window); i++ {
+		q.window[i] = zero
+	}
+	q.window = window
+	return removed
+}
+
+func (q *windowSortQueue[T]) appendTo(s []T) []T {
+	s = q.queue.appendTo(s)
+	return append(s, q.window...)
+}
+
+func (q *windowSortQueue[T]) reset() {
+	q.queue.reset()
+	q.clearWindow()
+}
+
+func (q *windowSortQueue[T]) clearWindow() {
+	var zero T
+	for i := range q.window {
+		q.window[i] = zero
+	}
+	q.window = q.window[:0]
+}
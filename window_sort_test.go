@@ -0,0 +1,107 @@
+package unlimitedchannel
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/pierrre/assert"
+)
+
+func TestWithWindowSort(t *testing.T) {
+	c := New(WithWindowSort(4, func(a, b int) bool {
+		return a < b
+	}))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	values := []int{3, 1, 4, 2, 8, 7, 5, 6, 9, 12, 10, 11, 13}
+	for _, v := range values {
+		in <- v
+	}
+	for i := 0; i < 3; i++ {
+		window := make([]int, 4)
+		for j := range window {
+			window[j] = <-out
+		}
+		assert.True(t, sort.IntsAreSorted(window))
+		expected := append([]int(nil), values[i*4:(i+1)*4]...)
+		sort.Ints(expected)
+		assert.SliceEqual(t, window, expected)
+	}
+	waitQueueLen(t, c, 1)
+	select {
+	case <-out:
+		t.Fatal("should not be here")
+	default:
+	}
+}
+
+func TestWithWindowSortSendAllOnClose(t *testing.T) {
+	c := New(WithWindowSort(4, func(a, b int) bool {
+		return a < b
+	}), WithSendAllOnClose[int](true))
+	in := c.In()
+	out := c.Out()
+	for _, v := range []int{3, 1, 4, 2, 7, 5, 6} {
+		in <- v
+	}
+	close(in)
+	var values []int
+	for v := range out {
+		values = append(values, v)
+	}
+	assert.SliceEqual(t, values, []int{1, 2, 3, 4, 5, 6, 7})
+}
+
+func TestWithWindowSortMaxCapacity(t *testing.T) {
+	c := New(WithWindowSort(5, func(a, b int) bool {
+		return a < b
+	}), WithMaxCapacity[int](3))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	for _, v := range []int{3, 1, 2} {
+		in <- v
+	}
+	for _, expected := range []int{1, 2, 3} {
+		assert.Equal(t, <-out, expected)
+	}
+}
+
+func TestWithWindowSortSoftLimit(t *testing.T) {
+	c := New(WithWindowSort(5, func(a, b int) bool {
+		return a < b
+	}), WithSoftLimit[int](3, time.Hour))
+	in := c.In()
+	out := c.Out()
+	defer close(in)
+	for _, v := range []int{3, 1, 2} {
+		in <- v
+	}
+	for _, expected := range []int{1, 2, 3} {
+		assert.Equal(t, <-out, expected)
+	}
+}
+
+func TestWithWindowSortDropOldest(t *testing.T) {
+	var dropped []int
+	c := New(WithWindowSort(5, func(a, b int) bool {
+		return a < b
+	}), WithMaxCapacity[int](3), WithDropOldest[int](true), WithSendAllOnClose[int](true), WithStartPaused[int](true), WithOnDrop(func(v int) {
+		dropped = append(dropped, v)
+	}))
+	in := c.In()
+	for _, v := range []int{5, 4, 3, 2, 1} {
+		in <- v
+	}
+	waitQueueLen(t, c, 3)
+	close(in)
+	c.Resume()
+	var values []int
+	for v := range c.Out() {
+		values = append(values, v)
+	}
+	assert.SliceEqual(t, dropped, []int{3, 4})
+	assert.SliceEqual(t, values, []int{5, 2, 1})
+}